@@ -570,7 +570,11 @@ func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Value }
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
-	out.WriteString(ie.Left.String())
+	// the left side is empty in df[, "col"]
+	if ie.Left != nil {
+		out.WriteString(ie.Left.String())
+	}
+
 	if ie.Operator != "::" && ie.Operator != "$" && ie.Operator != ".." {
 		out.WriteString(" ")
 	}
@@ -646,14 +650,14 @@ func (fl *FunctionLiteral) String() string {
 	}
 
 	if fl.Name != "" {
-		out.WriteString("#' @yield ")
+		out.WriteString("#' @return ")
 		for i, v := range fl.ReturnType {
 			out.WriteString(v.Name)
 			if i < len(fl.ReturnType)-1 {
 				out.WriteString(" | ")
 			}
-			out.WriteString("\n")
 		}
+		out.WriteString("\n")
 		out.WriteString(fl.Name)
 	}
 
@@ -828,3 +832,48 @@ func (d *DecoratorDefault) String() string {
 
 	return out.String()
 }
+
+type DecoratorExport struct {
+	Token token.Item // The 'export' token
+	Func  Expression
+	Type  *TypeStatement
+}
+
+func (d *DecoratorExport) Item() token.Item     { return d.Token }
+func (d *DecoratorExport) expressionNode()      {}
+func (d *DecoratorExport) TokenLiteral() string { return d.Token.Value }
+func (d *DecoratorExport) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("#' @export\n")
+
+	if d.Func != nil {
+		out.WriteString(d.Func.String())
+	}
+
+	if d.Type != nil {
+		out.WriteString(d.Type.String())
+	}
+
+	return out.String()
+}
+
+type DecoratorChecked struct {
+	Token token.Item // The 'checked' token
+	Func  Expression
+}
+
+func (d *DecoratorChecked) Item() token.Item     { return d.Token }
+func (d *DecoratorChecked) expressionNode()      {}
+func (d *DecoratorChecked) TokenLiteral() string { return d.Token.Value }
+func (d *DecoratorChecked) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("# checked\n")
+
+	if d.Func != nil {
+		out.WriteString(d.Func.String())
+	}
+
+	return out.String()
+}
@@ -0,0 +1,89 @@
+package ast
+
+import "reflect"
+
+// Inspect traverses the tree depth-first, calling f on each node,
+// the children of a node are skipped if f returns false.
+func Inspect(node Node, f func(Node) bool) {
+	if isNil(node) || !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+	case *ExpressionStatement:
+		Inspect(n.Expression, f)
+	case *LetStatement:
+		Inspect(n.Value, f)
+	case *ConstStatement:
+		Inspect(n.Value, f)
+	case *ReturnStatement:
+		Inspect(n.ReturnValue, f)
+	case *DeferStatement:
+		Inspect(n.Func, f)
+	case *BlockStatement:
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+	case *VectorLiteral:
+		for _, v := range n.Value {
+			Inspect(v, f)
+		}
+	case *For:
+		Inspect(n.Name, f)
+		Inspect(n.Vector, f)
+		Inspect(n.Value, f)
+	case *While:
+		Inspect(n.Statement, f)
+		Inspect(n.Value, f)
+	case *PrefixExpression:
+		Inspect(n.Right, f)
+	case *InfixExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Right, f)
+	case *IfExpression:
+		Inspect(n.Condition, f)
+		Inspect(n.Consequence, f)
+		Inspect(n.Alternative, f)
+	case *FunctionLiteral:
+		for _, p := range n.Parameters {
+			Inspect(p, f)
+		}
+		Inspect(n.Body, f)
+	case *Parameter:
+		Inspect(n.Default, f)
+	case *CallExpression:
+		for _, a := range n.Arguments {
+			Inspect(a.Value, f)
+		}
+	case *DecoratorClass:
+		Inspect(n.Type, f)
+	case *DecoratorMatrix:
+		Inspect(n.Type, f)
+	case *DecoratorFactor:
+		Inspect(n.Type, f)
+	case *DecoratorGeneric:
+		Inspect(n.Func, f)
+	case *DecoratorDefault:
+		Inspect(n.Func, f)
+	case *DecoratorExport:
+		Inspect(n.Func, f)
+		Inspect(n.Type, f)
+	case *DecoratorChecked:
+		Inspect(n.Func, f)
+	}
+}
+
+// nodes may be typed nil pointers, e.g.: a missing else block
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(node)
+
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
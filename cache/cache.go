@@ -1,21 +1,277 @@
 package cache
 
-var cache = make(map[string]interface{})
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
 
-func Set(key string, value interface{}) {
-	cache[key] = value
+// Entry is a cached value, it is invalidated when the installed
+// version of the package it was derived from changes.
+type Entry struct {
+	Package string          `json:"package,omitempty"`
+	Version string          `json:"version,omitempty"`
+	Value   json.RawMessage `json:"value"`
 }
 
-func Get(key string) (interface{}, bool) {
-	v, ok := cache[key]
-	return v, ok
+// Cache of R introspection results for an R installation,
+// identified by the R version and library paths.
+type Cache struct {
+	mu       sync.Mutex
+	path     string
+	dirty    bool
+	version  func(pkg string) string
+	versions map[string]string
+	R        string           `json:"r"`
+	Library  []string         `json:"library"`
+	Entries  map[string]Entry `json:"entries"`
 }
 
-func Has(key string) bool {
-	_, ok := cache[key]
-	return ok
+// Dir is where caches are stored, e.g.: ~/.cache/vapour
+func Dir() (string, error) {
+	dir, err := os.UserCacheDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "vapour"), nil
+}
+
+// New returns a cache that is not persisted.
+func New() *Cache {
+	return &Cache{
+		version:  func(string) string { return "" },
+		versions: make(map[string]string),
+		Entries:  make(map[string]Entry),
+	}
+}
+
+// Open reads the cache of an R installation from disk,
+// version returns the installed version of a package.
+func Open(r string, library []string, version func(pkg string) string) (*Cache, error) {
+	c := New()
+	c.R = r
+	c.Library = library
+	c.version = version
+
+	dir, err := Dir()
+
+	if err != nil {
+		return c, err
+	}
+
+	hash := sha256.Sum256([]byte(r + "\n" + strings.Join(library, "\n")))
+	c.path = filepath.Join(dir, hex.EncodeToString(hash[:8])+".json")
+
+	data, err := os.ReadFile(c.path)
+
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+
+	if err != nil {
+		return c, err
+	}
+
+	// a corrupt cache is started afresh
+	err = json.Unmarshal(data, c)
+
+	if err != nil || c.Entries == nil {
+		c.Entries = make(map[string]Entry)
+	}
+
+	return c, nil
+}
+
+// Get unmarshals the entry at key into a T,
+// stale entries are removed.
+func Get[T any](c *Cache, key string) (T, bool) {
+	var value T
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Entries[key]
+
+	if !ok {
+		return value, false
+	}
+
+	if entry.Package != "" && c.packageVersion(entry.Package) != entry.Version {
+		delete(c.Entries, key)
+		c.dirty = true
+		return value, false
+	}
+
+	err := json.Unmarshal(entry.Value, &value)
+
+	return value, err == nil
+}
+
+// Set stores value at key, pkg is the package value depends
+// on, if any. The cache is written to disk on Save.
+func Set[T any](c *Cache, key, pkg string, value T) error {
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := Entry{Value: data}
+
+	if pkg != "" {
+		entry.Package = pkg
+		entry.Version = c.packageVersion(pkg)
+	}
+
+	c.Entries[key] = entry
+	c.dirty = true
+
+	return nil
+}
+
+// Save writes the cache to disk if it changed,
+// once at the end of a run rather than on every Set
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	err := c.save()
+
+	if err != nil {
+		return err
+	}
+
+	c.dirty = false
+
+	return nil
 }
 
-func Clear() {
-	cache = make(map[string]interface{})
+// versions are read once per run
+func (c *Cache) packageVersion(pkg string) string {
+	v, ok := c.versions[pkg]
+
+	if ok {
+		return v
+	}
+
+	v = c.version(pkg)
+	c.versions[pkg] = v
+
+	return v
+}
+
+// written to a temporary file first so that
+// concurrent processes never read half a cache
+func (c *Cache) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(c)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(c.path), 0755)
+
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), "cache-*")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	tmp.Close()
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// Packages counts entries by package, base R is ""
+func (c *Cache) Packages() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, e := range c.Entries {
+		counts[e.Package]++
+	}
+
+	return counts
+}
+
+func (c *Cache) Path() string {
+	return c.path
+}
+
+// List reads all caches on disk, e.g.: one per R version.
+func List() ([]*Cache, error) {
+	var caches []*Cache
+
+	dir, err := Dir()
+
+	if err != nil {
+		return caches, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+
+	if err != nil {
+		return caches, err
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			continue
+		}
+
+		c := New()
+		c.path = path
+
+		if json.Unmarshal(data, c) != nil {
+			continue
+		}
+
+		caches = append(caches, c)
+	}
+
+	return caches, nil
+}
+
+// Clear removes all caches from disk.
+func Clear() error {
+	dir, err := Dir()
+
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dir)
 }
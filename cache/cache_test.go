@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	version := "1.0.0"
+	versions := func(pkg string) string {
+		return version
+	}
+
+	c, err := Open("R version 4.4.0", []string{"/lib"}, versions)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Set(c, "base", "", []string{"print", "paste"})
+			Set(c, "dplyr::filter", "dplyr", true)
+		}()
+	}
+	wg.Wait()
+
+	// nothing is written until saved
+	caches, _ := List()
+
+	if len(caches) != 0 {
+		t.Fatalf("expected no cache on disk before Save, got %v", len(caches))
+	}
+
+	err = c.Save()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// reopen from disk
+	c, err = Open("R version 4.4.0", []string{"/lib"}, versions)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fns, ok := Get[[]string](c, "base")
+
+	if !ok || len(fns) != 2 {
+		t.Fatalf("expected base functions, got %v", fns)
+	}
+
+	exists, ok := Get[bool](c, "dplyr::filter")
+
+	if !ok || !exists {
+		t.Fatal("expected dplyr::filter")
+	}
+
+	// package updated
+	version = "1.1.0"
+	c, _ = Open("R version 4.4.0", []string{"/lib"}, versions)
+
+	_, ok = Get[bool](c, "dplyr::filter")
+
+	if ok {
+		t.Fatal("expected dplyr::filter to be invalidated")
+	}
+
+	_, ok = Get[[]string](c, "base")
+
+	if !ok {
+		t.Fatal("expected base functions to remain")
+	}
+
+	// other R installation
+	c, _ = Open("R version 4.5.0", []string{"/lib"}, versions)
+
+	_, ok = Get[[]string](c, "base")
+
+	if ok {
+		t.Fatal("expected empty cache for another R version")
+	}
+
+	caches, _ = List()
+
+	if len(caches) != 1 {
+		t.Fatalf("expected 1 cache on disk, got %v", len(caches))
+	}
+
+	err = Clear()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caches, _ = List()
+
+	if len(caches) != 0 {
+		t.Fatalf("expected no cache, got %v", len(caches))
+	}
+}
@@ -31,20 +31,28 @@ func init() {
 }
 
 type CLI struct {
-	Indir    *string
-	Outdir   *string
-	LSP      *bool
-	TCP      *bool
-	Port     *string
-	Repl     *bool
-	Help     *bool
-	Version  *bool
-	Check    *bool
-	Run      *bool
-	Types    *string
-	Infile   *string
-	Outfile  *string
-	Devtools *string
+	Indir     *string
+	Outdir    *string
+	Layout    *string
+	Separator *string
+	LSP       *bool
+	TCP       *bool
+	Port      *string
+	Repl      *bool
+	Help      *bool
+	Version   *bool
+	Check     *bool
+	Run       *bool
+	Types     *string
+	Namespace *bool
+	Roxygen   *bool
+	Checked   *bool
+	SourceMap *bool
+	Infile    *string
+	Outfile   *string
+	Devtools  *string
+	RSnapshot *string
+	Cache     *string
 }
 
 func Cli() CLI {
@@ -54,9 +62,25 @@ func Cli() CLI {
 	infile := flag.String("infile", "", "Vapour file to process")
 	outfile := flag.String("outfile", "vapour.R", "Name of R file to where to palce transpiled `infile`. (defaults to vapour.R)")
 
+	// layout
+	layout := flag.String("layout", "single", "How to write files from `indir` to `outdir`: `single` file (-outfile), one file per vapour file in a `tree` mirroring `indir` (not with -outdir R, R does not load subdirectories), or `flat` (defaults to single)")
+	separator := flag.String("separator", "-", "Separator replacing directories in file names with -layout flat, e.g.: models/user.vp becomes models-user.R (defaults to -)")
+
 	// types
 	types := flag.String("types", "inst/types.vp", "Path where to generate the type files, only applies if passing a directory with -indir")
 
+	// namespace
+	namespace := flag.Bool("namespace", false, "Write the NAMESPACE file from @export decorators in the parent of -outdir, only applies if passing a directory with -indir and -outdir is an R directory")
+
+	// documentation
+	roxygen := flag.Bool("roxygen", false, "Generate roxygen2 documentation from function signatures")
+
+	// runtime checks
+	checked := flag.Bool("checked", false, "Emit runtime type checks of arguments, return values, and untyped assignments in all functions")
+
+	// source maps
+	sourcemap := flag.Bool("sourcemap", false, "Write a source map next to the transpiled file (e.g.: vapour.R.map) mapping R lines to vapour files")
+
 	// run type checker
 	check := flag.Bool("check-only", false, "Run type checker")
 
@@ -77,21 +101,34 @@ func Cli() CLI {
 	// devtools
 	devtools := flag.String("devtools", "", "Run {devtools} functions after transpilation, accepts `document`, `check`, `install`, separate by comma (e.g.: `document,check`)")
 
+	// r
+	rsnapshot := flag.String("r-snapshot", "", "Answer queries about R (e.g.: installed packages) from a JSON snapshot rather than running R, e.g.: on CI")
+
+	rcache := flag.String("cache", "", "Manage the cache of R queries: `show` where it is and what it holds, or `clear` it")
+
 	flag.Parse()
 
 	return CLI{
-		Indir:    indir,
-		Outdir:   outdir,
-		LSP:      lsp,
-		TCP:      tcp,
-		Port:     port,
-		Infile:   infile,
-		Outfile:  outfile,
-		Repl:     repl,
-		Check:    check,
-		Run:      run,
-		Version:  version,
-		Types:    types,
-		Devtools: devtools,
+		Indir:     indir,
+		Outdir:    outdir,
+		Layout:    layout,
+		Separator: separator,
+		LSP:       lsp,
+		TCP:       tcp,
+		Port:      port,
+		Infile:    infile,
+		Outfile:   outfile,
+		Repl:      repl,
+		Check:     check,
+		Run:       run,
+		Version:   version,
+		Types:     types,
+		Namespace: namespace,
+		Roxygen:   roxygen,
+		Checked:   checked,
+		SourceMap: sourcemap,
+		Devtools:  devtools,
+		RSnapshot: rsnapshot,
+		Cache:     rcache,
 	}
 }
@@ -15,6 +15,9 @@ type lspConfig struct {
 
 type Config struct {
 	Lsp *lspConfig `json:"lsp"`
+	// directories of package types searched before
+	// the library, e.g.: <dir>/dplyr/1.1/types.vp
+	Stubs []string `json:"stubs"`
 }
 
 func makeConfigPath(conf string) string {
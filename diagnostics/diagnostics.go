@@ -3,6 +3,7 @@ package diagnostics
 import (
 	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/vapourlang/vapour/cli"
 	"github.com/vapourlang/vapour/token"
@@ -19,9 +20,17 @@ const (
 )
 
 type Diagnostic struct {
-	Token    token.Item
-	Message  string
-	Severity Severity
+	Token       token.Item
+	Message     string
+	Severity    Severity
+	Suggestions []Suggestion
+}
+
+// Suggestion is a name the user may have meant,
+// it replaces the text of Token, e.g.: a typo
+type Suggestion struct {
+	Token token.Item
+	Value string
 }
 
 type Diagnostics []Diagnostic
@@ -84,10 +93,25 @@ func (v Diagnostic) String() string {
 	out.WriteString(fmt.Sprintf("%v", v.Token.Line))
 	out.WriteString(":")
 	out.WriteString(fmt.Sprintf("%v", v.Token.Char))
-	out.WriteString(" " + v.Message + "\n")
+	out.WriteString(" " + v.Message)
+	out.WriteString(didYouMean(v.Suggestions))
+	out.WriteString("\n")
 	return out.String()
 }
 
+func didYouMean(suggestions []Suggestion) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	var names []string
+	for _, s := range suggestions {
+		names = append(names, "`"+s.Value+"`")
+	}
+
+	return ", did you mean " + strings.Join(names, " or ") + "?"
+}
+
 func (d Diagnostics) Print() {
 	fmt.Printf("%v", d.String())
 }
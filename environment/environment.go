@@ -2,6 +2,7 @@ package environment
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/vapourlang/vapour/ast"
 	"github.com/vapourlang/vapour/r"
@@ -17,6 +18,7 @@ type Environment struct {
 	signature  map[string]Signature
 	method     map[string]Methods
 	returnType ast.Types
+	packages   map[string]TypeSource
 	outer      *Environment
 }
 
@@ -26,6 +28,14 @@ func SetLibrary(paths []string) {
 	library = paths
 }
 
+var stubPaths []string
+
+// SetStubPaths sets the directories searched for package
+// types before the library, in order of precedence.
+func SetStubPaths(paths []string) {
+	stubPaths = paths
+}
+
 func Enclose(outer *Environment, t ast.Types) *Environment {
 	env := New()
 	env.returnType = t
@@ -73,7 +83,7 @@ var baseObjects = []string{
 	"impliedList",
 }
 
-func NewGlobalEnvironment() *Environment {
+func NewGlobalEnvironment(backend r.Backend) *Environment {
 	v := make(map[string]Variable)
 	t := make(map[string]Type)
 	f := make(map[string]Function)
@@ -92,6 +102,7 @@ func NewGlobalEnvironment() *Environment {
 		signature: s,
 		factor:    fct,
 		method:    meth,
+		packages:  make(map[string]TypeSource),
 		outer:     nil,
 	}
 
@@ -99,11 +110,10 @@ func NewGlobalEnvironment() *Environment {
 		env.SetType(Type{Used: true, Name: t, Type: []*ast.Type{{Name: t, List: false}}})
 	}
 
-	fns, err := r.ListBaseFunctions()
+	fns, err := backend.BaseFunctions()
 
 	if err != nil {
 		fmt.Printf("failed to fetch base R functions: %v", err.Error())
-		return env
 	}
 
 	for _, pkg := range fns {
@@ -112,6 +122,12 @@ func NewGlobalEnvironment() *Environment {
 		}
 	}
 
+	err = env.LoadStubs()
+
+	if err != nil {
+		fmt.Printf("failed to load stubs: %v\n", err.Error())
+	}
+
 	return env
 }
 
@@ -134,6 +150,7 @@ func New() *Environment {
 		signature: s,
 		factor:    fct,
 		method:    meth,
+		packages:  make(map[string]TypeSource),
 		outer:     nil,
 	}
 }
@@ -152,14 +169,19 @@ func (e *Environment) SetSignatureUsed(name string) (Signature, bool) {
 }
 
 func (e *Environment) SetTypeUsed(pkg, name string) (Type, bool) {
-	obj, ok := e.types[makeTypeKey(pkg, name)]
+	key := makeTypeKey(pkg, name)
+	obj, ok := e.types[key]
 
 	if !ok && e.outer != nil {
 		return e.outer.SetTypeUsed(pkg, name)
 	}
 
+	if !ok {
+		return obj, ok
+	}
+
 	obj.Used = true
-	e.types[name] = obj
+	e.types[key] = obj
 
 	return obj, ok
 }
@@ -316,17 +338,14 @@ func (e *Environment) GetMethods(name string) (Methods, bool) {
 }
 
 func (e *Environment) GetMethod(name string, t *ast.Type) (Method, bool) {
-	obj, ok := e.method[name]
-	if !ok && e.outer != nil {
-		obj, ok = e.outer.GetMethods(name)
-	}
+	obj, ok := e.GetMethods(name)
 
 	if !ok {
 		return Method{}, false
 	}
 
 	for _, o := range obj {
-		if o.Value.Method == t {
+		if o.Value.Method.Name == t.Name {
 			return o, true
 		}
 	}
@@ -335,25 +354,94 @@ func (e *Environment) GetMethod(name string, t *ast.Type) (Method, bool) {
 }
 
 func (e *Environment) HasMethods(name string, t *ast.Type) bool {
-	obj, ok := e.GetMethods(name)
+	_, ok := e.GetMethod(name, t)
+	return ok
+}
 
-	if !ok {
-		return false
+// Classes is the class vector of t in R, the type itself
+// unless its @class decorator replaces it.
+func (e *Environment) Classes(t *ast.Type) []string {
+	class, ok := e.GetClass(makeTypeKey(t.Package, t.Name))
+
+	if !ok || len(class.Value.Classes) == 0 {
+		return []string{t.Name}
 	}
 
-	for _, o := range obj {
-		if o.Value.Method == t {
-			return true
+	return class.Value.Classes
+}
+
+// Dispatch resolves the method of ms R calls on t: the first
+// method along the class vector of t, then the @default.
+func (e *Environment) Dispatch(ms Methods, t *ast.Type) (Method, bool) {
+	for _, class := range e.Classes(t) {
+		for _, m := range ms {
+			if m.Generic || m.Value.Method.Name != class {
+				continue
+			}
+
+			return m, true
+		}
+	}
+
+	for _, m := range ms {
+		if m.Default {
+			return m, true
 		}
 	}
 
-	return false
+	return Method{}, false
+}
+
+// Generic returns the @generic declaration among ms
+func (ms Methods) Generic() (Method, bool) {
+	for _, m := range ms {
+		if m.Generic {
+			return m, true
+		}
+	}
+
+	return Method{}, false
 }
 
 func (e *Environment) Types() map[string]Type {
 	return e.types
 }
 
+// Names lists the variables, functions, types and signatures
+// in scope, excluding those read from the types of packages.
+func (e *Environment) Names() []string {
+	var names []string
+	add := func(name string) {
+		if !strings.Contains(name, "::") {
+			names = append(names, name)
+		}
+	}
+
+	for env := e; env != nil; env = env.outer {
+		for name := range env.variables {
+			add(name)
+		}
+
+		for name := range env.functions {
+			add(name)
+		}
+
+		for name := range env.types {
+			add(name)
+		}
+
+		for name := range env.signature {
+			add(name)
+		}
+	}
+
+	return names
+}
+
+func (e *Environment) Methods() map[string]Methods {
+	return e.method
+}
+
 func (e *Environment) Variables() map[string]Variable {
 	return e.variables
 }
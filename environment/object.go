@@ -7,19 +7,26 @@ import (
 
 // this should be an interface but I haven't got the time right now
 type Function struct {
-	Token   token.Item
-	Package string
-	Value   *ast.FunctionLiteral
-	Name    string
+	Token    token.Item
+	Package  string
+	Value    *ast.FunctionLiteral
+	Name     string
+	Exported bool
+	// functions from packages we have the signature of
+	Typed bool
 }
 
 type Methods []Method
 
 type Method struct {
-	Token   token.Item
-	Package string
-	Value   *ast.FunctionLiteral
-	Name    string
+	Token    token.Item
+	Package  string
+	Value    *ast.FunctionLiteral
+	Name     string
+	Exported bool
+	// declared with @generic or @default, on any
+	Generic bool
+	Default bool
 }
 
 type Variable struct {
@@ -39,6 +46,7 @@ type Type struct {
 	Used       bool
 	Object     string
 	Name       string
+	Exported   bool
 	Attributes []*ast.TypeAttributesStatement
 }
 
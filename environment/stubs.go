@@ -0,0 +1,92 @@
+package environment
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/vapourlang/vapour/ast"
+	"github.com/vapourlang/vapour/diagnostics"
+	"github.com/vapourlang/vapour/lexer"
+	"github.com/vapourlang/vapour/parser"
+)
+
+// typed declarations of the most used base R functions,
+// one file per package, e.g.: stubs/stats.vp
+//
+//go:embed stubs/*.vp
+var stubs embed.FS
+
+// LoadStubs registers the functions declared in the bundled
+// stubs, replacing the untyped functions listed by R.
+func (env *Environment) LoadStubs() error {
+	files, err := stubs.ReadDir("stubs")
+
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		file := path.Join("stubs", f.Name())
+		content, err := stubs.ReadFile(file)
+
+		if err != nil {
+			return err
+		}
+
+		pkg := strings.TrimSuffix(f.Name(), ".vp")
+
+		err = env.loadStub(file, pkg, string(content))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (env *Environment) loadStub(file, pkg, content string) error {
+	l := lexer.NewCode(file, content)
+	l.Run()
+
+	if l.HasError() {
+		return stubError(l.Errors()[0])
+	}
+
+	p := parser.New(l)
+	prog := p.Run()
+
+	if p.HasError() {
+		return stubError(p.Errors()[0])
+	}
+
+	for _, s := range prog.Statements {
+		stmt, ok := s.(*ast.ExpressionStatement)
+
+		if !ok {
+			continue
+		}
+
+		fn, ok := stmt.Expression.(*ast.FunctionLiteral)
+
+		if !ok {
+			continue
+		}
+
+		env.SetFunction(fn.Name, Function{
+			Token:   fn.Token,
+			Value:   fn,
+			Name:    fn.Name,
+			Package: pkg,
+			Typed:   true,
+		})
+	}
+
+	return nil
+}
+
+func stubError(d diagnostics.Diagnostic) error {
+	return fmt.Errorf("%v:%v:%v %v", d.Token.File, d.Token.Line+1, d.Token.Char, d.Message)
+}
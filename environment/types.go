@@ -1,70 +1,221 @@
 package environment
 
 import (
-	"errors"
+	"fmt"
 	"os"
-	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/vapourlang/vapour/ast"
 	"github.com/vapourlang/vapour/lexer"
 	"github.com/vapourlang/vapour/parser"
+	"github.com/vapourlang/vapour/r"
 )
 
 type Code struct {
-	lines []string
+	lines    []string
+	warnings []error
 }
 
 func (c *Code) add(line string) {
 	c.lines = append(c.lines, line)
 }
 
+// Warnings lists what could not be written as declared,
+// the types are still valid
+func (c *Code) Warnings() []error {
+	return c.warnings
+}
+
 func (c *Code) String() string {
 	return strings.Join(c.lines, "\n")
 }
 
+// GenerateTypes writes the types declared in the package,
+// sorted by name so the file only changes with the types.
 func (e *Environment) GenerateTypes() *Code {
 	code := &Code{}
 
-	for typeName, typeObject := range e.types {
-		if IsNativeType(typeName) || IsNativeObject(typeName) {
+	var names []string
+	for name, t := range e.types {
+		// types imported from other packages
+		if t.Package != "" {
 			continue
 		}
 
-		class, classExists := e.GetClass(typeName)
+		if IsNativeType(name) || IsNativeObject(name) {
+			continue
+		}
 
-		if classExists {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		typeObject := e.types[name]
+
+		class, ok := e.GetClass(name)
+		if ok {
 			code.add("@class(" + strings.Join(class.Value.Classes, ", ") + ")")
 		}
 
-		curlyLeft := "{"
-		if len(typeObject.Attributes) == 0 {
-			curlyLeft = ""
+		matrix, ok := e.GetMatrix(name)
+		if ok {
+			code.add("@matrix(" + decoratorArguments(matrix.Value.Arguments) + ")")
+		}
+
+		factor, ok := e.GetFactor(name)
+		if ok {
+			code.add("@factor(" + decoratorArguments(factor.Value.Arguments) + ")")
 		}
 
-		if typeObject.Object != "impliedList" {
-			code.add("type " + typeName + ": " + typeObject.Object + " " + curlyLeft)
+		code.add(typeDeclaration(typeObject))
+	}
+
+	e.exportedSignatures(code)
+
+	return code
+}
+
+// signatures of exported functions and methods, without
+// body, so packages importing ours can check their calls
+func (e *Environment) exportedSignatures(code *Code) {
+	var names []string
+	for name, fn := range e.functions {
+		if fn.Exported {
+			names = append(names, name)
 		}
+	}
 
-		if typeObject.Object == "impliedList" {
-			code.add("type " + typeName + ": " + collaseTypes(typeObject.Type) + " " + curlyLeft)
+	for name, methods := range e.method {
+		for _, m := range methods {
+			if m.Exported {
+				names = append(names, name)
+				break
+			}
 		}
+	}
+
+	sort.Strings(names)
 
-		if len(typeObject.Attributes) == 0 {
+	for i, name := range names {
+		if i > 0 && names[i-1] == name {
 			continue
 		}
 
-		for i, a := range typeObject.Attributes {
-			sep := ""
-			if i < len(typeObject.Attributes)-1 {
-				sep = ","
+		fn, ok := e.functions[name]
+
+		if ok && fn.Exported {
+			code.signature(fn.Value)
+		}
+
+		for _, m := range e.method[name] {
+			if !m.Exported {
+				continue
+			}
+
+			if m.Generic {
+				code.add("@generic")
+			}
+
+			if m.Default {
+				code.add("@default")
 			}
-			code.add("\t" + a.Name + ": " + collaseTypes(a.Type) + sep)
+
+			code.signature(m.Value)
 		}
-		code.add("}")
 	}
+}
 
-	return code
+func (c *Code) signature(fn *ast.FunctionLiteral) {
+	var params []string
+	for _, p := range fn.Parameters {
+		param := p.Name + ": " + collaseTypes(p.Type)
+
+		if p.Default != nil {
+			value, ok := defaultValue(p.Default.Expression)
+
+			// the argument is still optional
+			if !ok {
+				value = "NULL"
+				c.warnings = append(
+					c.warnings,
+					fmt.Errorf("default `%v` of `%v` in `%v` written as NULL", p.Default.Expression.String(), p.Name, fn.Name),
+				)
+			}
+
+			param += " = " + value
+		}
+
+		params = append(params, param)
+	}
+
+	method := ""
+	if fn.Method != nil {
+		method = "(" + fn.MethodVariable + ": " + collaseTypes(ast.Types{fn.Method}) + ") "
+	}
+
+	code := "func " + method + fn.Name + "(" + strings.Join(params, ", ") + ")"
+
+	if len(fn.ReturnType) > 0 {
+		code += ": " + collaseTypes(fn.ReturnType)
+	}
+
+	c.add(code)
+}
+
+// only literals can be read back from the types file,
+// e.g.: -1 but not a call
+func defaultValue(value ast.Expression) (string, bool) {
+	switch v := value.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean, *ast.Null, *ast.Keyword:
+		return value.String(), true
+	case *ast.PrefixExpression:
+		right, ok := defaultValue(v.Right)
+		return v.Operator + right, ok
+	}
+
+	return "", false
+}
+
+// declared as the parser expects each object
+func typeDeclaration(t Type) string {
+	declaration := "type " + t.Name + ": "
+
+	switch t.Object {
+	case "vector", "impliedList":
+		return declaration + collaseTypes(t.Type)
+	case "list", "matrix", "factor":
+		return declaration + t.Object + " { " + collaseTypes(t.Type) + " }"
+	}
+
+	var lines []string
+
+	if t.Object == "struct" {
+		lines = append(lines, "\t"+collaseTypes(t.Type))
+	}
+
+	for _, a := range t.Attributes {
+		lines = append(lines, "\t"+a.Name+": "+collaseTypes(a.Type))
+	}
+
+	if len(lines) == 0 {
+		return declaration + t.Object + " {}"
+	}
+
+	return declaration + t.Object + " {\n" + strings.Join(lines, ",\n") + "\n}"
+}
+
+// named arguments are parsed as assignments, e.g.: nrow = 2
+func decoratorArguments(args []ast.Argument) string {
+	var str []string
+	for _, a := range args {
+		str = append(str, a.Value.String())
+	}
+
+	return strings.Join(str, ", ")
 }
 
 func collaseTypes(types []*ast.Type) string {
@@ -76,6 +227,10 @@ func collaseTypes(types []*ast.Type) string {
 			typeString += "[]"
 		}
 
+		if t.Package != "" {
+			typeString += t.Package + "::"
+		}
+
 		typeString += t.Name
 
 		str = append(str, typeString)
@@ -102,75 +257,261 @@ func IsNativeObject(name string) bool {
 	return false
 }
 
-var packagesLoaded []string
+// TypeSource is the file the types of a package were read from
+type TypeSource struct {
+	Path string
+	// from a stub directory rather than the package itself
+	Stub bool
+}
 
-func isLoaded(library string) bool {
-	for _, p := range packagesLoaded {
-		if p == library {
-			return true
+// LoadPackageTypes reads the types of pkg into the global
+// environment, it returns where they were read from the
+// first time they are loaded.
+func (env *Environment) LoadPackageTypes(pkg string) (TypeSource, bool) {
+	root := env
+	for root.outer != nil {
+		root = root.outer
+	}
+
+	if _, ok := root.packages[pkg]; ok {
+		return TypeSource{}, false
+	}
+
+	source, ok := findTypes(pkg)
+	root.packages[pkg] = source
+
+	if !ok {
+		return source, false
+	}
+
+	content, err := os.ReadFile(source.Path)
+
+	if err != nil {
+		return source, false
+	}
+
+	// lex
+	l := lexer.NewCode(source.Path, string(content))
+	l.Run()
+
+	if l.HasError() {
+		return source, false
+	}
+
+	// parse
+	p := parser.New(l)
+	prog := p.Run()
+
+	if p.HasError() {
+		return source, false
+	}
+
+	// range over the Statements
+	// these should all be type declarations
+	// or function signatures
+	var signatures []Method
+	for _, p := range prog.Statements {
+		switch node := p.(type) {
+		case *ast.TypeStatement:
+			root.setPackageType(pkg, node)
+		case *ast.ExpressionStatement:
+			switch n := node.Expression.(type) {
+			case *ast.DecoratorClass:
+				root.setPackageType(pkg, n.Type)
+				root.SetClass(makeTypeKey(pkg, n.Type.Name), Class{Token: n.Token, Value: n})
+				continue
+			case *ast.DecoratorMatrix:
+				root.setPackageType(pkg, n.Type)
+				root.SetMatrix(makeTypeKey(pkg, n.Type.Name), Matrix{Token: n.Token, Value: n})
+				continue
+			case *ast.DecoratorFactor:
+				root.setPackageType(pkg, n.Type)
+				root.SetFactor(makeTypeKey(pkg, n.Type.Name), Factor{Token: n.Token, Value: n})
+				continue
+			}
+
+			fn, ok := signatureLiteral(node.Expression)
+
+			if !ok {
+				continue
+			}
+
+			_, generic := node.Expression.(*ast.DecoratorGeneric)
+			_, def := node.Expression.(*ast.DecoratorDefault)
+
+			signatures = append(signatures, Method{Value: fn, Generic: generic, Default: def})
 		}
 	}
 
-	return false
-}
+	// signatures may use types declared after them
+	for _, sig := range signatures {
+		fn := sig.Value
+		root.qualifyTypes(pkg, fn)
+
+		if fn.Method == nil {
+			root.SetFunction(
+				makeTypeKey(pkg, fn.Name),
+				Function{
+					Token:    fn.Token,
+					Value:    fn,
+					Name:     fn.Name,
+					Package:  pkg,
+					Exported: true,
+					Typed:    true,
+				},
+			)
+			continue
+		}
 
-func (env *Environment) LoadPackageTypes(pkg string) {
-	if len(library) == 0 {
-		return
+		root.AddMethod(
+			makeTypeKey(pkg, fn.Name),
+			Method{
+				Token:    fn.Token,
+				Value:    fn,
+				Name:     fn.Name,
+				Package:  pkg,
+				Exported: true,
+				Generic:  sig.Generic,
+				Default:  sig.Default,
+			},
+		)
 	}
 
-	if isLoaded(pkg) {
+	return source, true
+}
+
+func (env *Environment) setPackageType(pkg string, node *ast.TypeStatement) {
+	if node == nil {
 		return
 	}
 
-	packagesLoaded = append(packagesLoaded, pkg)
+	env.SetType(
+		Type{
+			Token:      node.Token,
+			Type:       node.Type,
+			Attributes: node.Attributes,
+			Object:     node.Object,
+			Name:       node.Name,
+			Package:    pkg,
+			Used:       true,
+		},
+	)
+}
 
-	for _, lib := range library {
-		typeFile := path.Join(lib, pkg, "types.vp")
+// GetPackageFunction returns the signature of an exported
+// function read from the types of pkg, e.g.: dplyr::filter
+func (env *Environment) GetPackageFunction(pkg, name string) (Function, bool) {
+	return env.GetFunction(makeTypeKey(pkg, name), true)
+}
 
-		if _, err := os.Stat(typeFile); errors.Is(err, os.ErrNotExist) {
-			continue
+// PackageFunctions lists the functions declared in the types of pkg
+func (env *Environment) PackageFunctions(pkg string) []string {
+	var names []string
+	for e := env; e != nil; e = e.outer {
+		for name := range e.functions {
+			fn, ok := strings.CutPrefix(name, pkg+"::")
+
+			if ok {
+				names = append(names, fn)
+			}
 		}
+	}
+
+	return names
+}
 
-		content, err := os.ReadFile(typeFile)
+// GetPackageMethods returns the methods of pkg on a generic
+func (env *Environment) GetPackageMethods(pkg, name string) (Methods, bool) {
+	return env.GetMethods(makeTypeKey(pkg, name))
+}
 
-		if err != nil {
-			continue
+func signatureLiteral(node ast.Expression) (*ast.FunctionLiteral, bool) {
+	switch n := node.(type) {
+	case *ast.FunctionLiteral:
+		return n, n.Name != ""
+	case *ast.DecoratorGeneric:
+		return signatureLiteral(n.Func)
+	case *ast.DecoratorDefault:
+		return signatureLiteral(n.Func)
+	}
+
+	return nil, false
+}
+
+// within the package its types are unqualified,
+// importers know them as pkg::type
+func (env *Environment) qualifyTypes(pkg string, fn *ast.FunctionLiteral) {
+	qualify := func(types ast.Types) {
+		for _, t := range types {
+			if t.Package != "" {
+				continue
+			}
+
+			if _, ok := env.types[makeTypeKey(pkg, t.Name)]; ok {
+				t.Package = pkg
+			}
 		}
+	}
 
-		// lex
-		l := lexer.NewCode(typeFile, string(content))
-		l.Run()
+	if fn.Method != nil {
+		qualify(ast.Types{fn.Method})
+	}
 
-		if l.HasError() {
-			continue
+	qualify(fn.ReturnType)
+
+	for _, p := range fn.Parameters {
+		qualify(p.Type)
+	}
+}
+
+// stub directories are searched first, in order, then the
+// package itself: <lib>/<pkg>/types.vp
+func findTypes(pkg string) (TypeSource, bool) {
+	version := r.PackageVersion(library, pkg)
+
+	for _, dir := range stubPaths {
+		for _, v := range versionDirs(version) {
+			file := filepath.Join(dir, pkg, v, "types.vp")
+
+			if exists(file) {
+				return TypeSource{Path: file, Stub: true}, true
+			}
 		}
+	}
 
-		// parse
-		p := parser.New(l)
-		prog := p.Run()
+	for _, lib := range library {
+		file := filepath.Join(lib, pkg, "types.vp")
 
-		if p.HasError() {
-			continue
+		if exists(file) {
+			return TypeSource{Path: file}, true
 		}
+	}
 
-		// range over the Statements
-		// these should all be type declarations
-		for _, p := range prog.Statements {
-			switch node := p.(type) {
-			case *ast.TypeStatement:
-				env.SetType(
-					Type{
-						Token:      node.Token,
-						Type:       node.Type,
-						Attributes: node.Attributes,
-						Object:     node.Object,
-						Name:       node.Name,
-						Package:    pkg,
-						Used:       true,
-					},
-				)
-			}
+	return TypeSource{}, false
+}
+
+// stubs can be pinned to a version, the most specific
+// directory wins, e.g.: 1.1.4, 1.1, 1, then unversioned,
+// R versions are separated by dots or dashes, e.g.: 1.1-4
+func versionDirs(version string) []string {
+	var dirs []string
+
+	for version != "" {
+		dirs = append(dirs, version)
+
+		i := strings.LastIndexAny(version, ".-")
+
+		if i < 0 {
+			break
 		}
+
+		version = version[:i]
 	}
+
+	return append(dirs, "")
+}
+
+func exists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
 }
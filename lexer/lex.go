@@ -491,6 +491,16 @@ func lexDecorator(l *Lexer) stateFn {
 		return lexDefault
 	}
 
+	if tok == "export" {
+		l.emit(token.ItemDecoratorExport)
+		return lexDefault
+	}
+
+	if tok == "checked" {
+		l.emit(token.ItemDecoratorChecked)
+		return lexDefault
+	}
+
 	if tok == "class" {
 		l.emit(token.ItemDecoratorClass)
 	}
@@ -1,6 +1,7 @@
 package lsp
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -12,14 +13,17 @@ import (
 	"github.com/vapourlang/vapour/diagnostics"
 	"github.com/vapourlang/vapour/lexer"
 	"github.com/vapourlang/vapour/parser"
+	"github.com/vapourlang/vapour/r"
+	"github.com/vapourlang/vapour/token"
 	"github.com/vapourlang/vapour/walker"
 )
 
 var src string = "Vapour"
 
 type LSP struct {
-	files []lexer.File
-	conf  *config.Config
+	files   []lexer.File
+	conf    *config.Config
+	backend r.Backend
 }
 
 type walkParams struct {
@@ -46,16 +50,19 @@ func New() *LSP {
 	}
 }
 
-func Run(conf *config.Config, tcp bool, port string) {
+func Run(conf *config.Config, backend r.Backend, tcp bool, port string) {
 	l := New()
 
 	l.conf = conf
+	l.backend = backend
 
 	handler = protocol.Handler{
 		Initialize:  l.initialize,
 		Initialized: l.initialized,
 		Shutdown:    l.shutdown,
 		SetTrace:    l.setTrace,
+
+		TextDocumentCodeAction: l.textDocumentCodeAction,
 	}
 
 	if contains("open", conf.Lsp.When) {
@@ -126,6 +133,12 @@ func (l *LSP) initialized(context *glsp.Context, params *protocol.InitializedPar
 
 func (l *LSP) shutdown(context *glsp.Context) error {
 	protocol.SetTraceValue(protocol.TraceValueOff)
+
+	// the client may exit the server before main returns
+	if c, ok := l.backend.(interface{ Save() error }); ok {
+		return c.Save()
+	}
+
 	return nil
 }
 
@@ -183,7 +196,7 @@ func (l *LSP) walkFiles(context *glsp.Context, params *walkParams) error {
 	}
 
 	// walk tree
-	w := walker.New()
+	w := walker.New(l.backend)
 	w.Walk(prog)
 
 	diagnostics = addError(diagnostics, w.Errors(), file, l.conf.Lsp.Severity)
@@ -255,25 +268,83 @@ func addError(ds []protocol.Diagnostic, ns diagnostics.Diagnostics, file string,
 
 		s := protocol.DiagnosticSeverity(e.Severity)
 
-		ds = append(
-			ds,
-			protocol.Diagnostic{
-				Range: protocol.Range{
-					Start: protocol.Position{
-						Line:      uint32(e.Token.Line),
-						Character: uint32(e.Token.Char - len(e.Token.Value)),
-					},
-					End: protocol.Position{
-						Line:      uint32(e.Token.Line),
-						Character: uint32(e.Token.Char),
+		// suggestions travel with the diagnostic, the
+		// client sends them back when asking for fixes
+		var fixes []protocol.TextEdit
+		for _, sg := range e.Suggestions {
+			fixes = append(fixes, protocol.TextEdit{
+				Range:   tokenRange(sg.Token),
+				NewText: sg.Value,
+			})
+		}
+
+		d := protocol.Diagnostic{
+			Range:    tokenRange(e.Token),
+			Severity: &s,
+			Code:     &code,
+			Source:   &src,
+			Message:  e.Message,
+		}
+
+		if len(fixes) > 0 {
+			d.Data = fixes
+		}
+
+		ds = append(ds, d)
+	}
+	return ds
+}
+
+func tokenRange(tok token.Item) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{
+			Line:      uint32(tok.Line),
+			Character: uint32(tok.Char - len(tok.Value)),
+		},
+		End: protocol.Position{
+			Line:      uint32(tok.Line),
+			Character: uint32(tok.Char),
+		},
+	}
+}
+
+// quick fixes replace a misspelled name with a suggestion
+func (l *LSP) textDocumentCodeAction(context *glsp.Context, params *protocol.CodeActionParams) (any, error) {
+	actions := []protocol.CodeAction{}
+	kind := protocol.CodeActionKindQuickFix
+
+	for _, d := range params.Context.Diagnostics {
+		if d.Data == nil {
+			continue
+		}
+
+		// data is decoded as a generic value
+		data, err := json.Marshal(d.Data)
+
+		if err != nil {
+			continue
+		}
+
+		var fixes []protocol.TextEdit
+		err = json.Unmarshal(data, &fixes)
+
+		if err != nil {
+			continue
+		}
+
+		for _, fix := range fixes {
+			actions = append(actions, protocol.CodeAction{
+				Title:       fmt.Sprintf("Change to `%v`", fix.NewText),
+				Kind:        &kind,
+				Diagnostics: []protocol.Diagnostic{d},
+				Edit: &protocol.WorkspaceEdit{
+					Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+						params.TextDocument.URI: {fix},
 					},
 				},
-				Severity: &s,
-				Code:     &code,
-				Source:   &src,
-				Message:  e.Message,
-			},
-		)
+			})
+		}
 	}
-	return ds
+
+	return actions, nil
 }
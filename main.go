@@ -1,11 +1,22 @@
 package main
 
 import (
+	"os"
+
 	"github.com/vapourlang/vapour/cli"
+	"github.com/vapourlang/vapour/r"
 )
 
 func main() {
 	v := New()
+	defer r.Close()
+
+	// subcommands come before flags
+	if len(os.Args) > 1 && os.Args[1] == "stubgen" {
+		v.stubgen(os.Args[2:])
+		return
+	}
+
 	args := cli.Cli()
 	v.Run(args)
 }
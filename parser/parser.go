@@ -99,6 +99,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.ItemDecoratorDefault, p.parseDecoratorDefault)
 	p.registerPrefix(token.ItemDecoratorMatrix, p.parseDecoratorMatrix)
 	p.registerPrefix(token.ItemDecoratorFactor, p.parseDecoratorFactor)
+	p.registerPrefix(token.ItemDecoratorExport, p.parseDecoratorExport)
+	p.registerPrefix(token.ItemDecoratorChecked, p.parseDecoratorChecked)
 	p.registerPrefix(token.ItemRightSquare, p.parseSquare)
 	p.registerPrefix(token.ItemDoubleRightSquare, p.parseSquare)
 
@@ -454,8 +456,6 @@ func (p *Parser) parseTypeDeclarationFunc() *ast.TypeFunction {
 		return nil
 	}
 
-	p.nextToken()
-
 	fn.Return = p.parseTypes()
 
 	return fn
@@ -854,6 +854,19 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 
+	// df[, "col"] the row index is empty, keep
+	// the column with the square it indexes
+	_, empty := expression.Right.(*ast.Comma)
+	if operator == "[" && empty && !p.peekTokenIs(token.ItemRightSquare) {
+		column := &ast.InfixExpression{
+			Token:    p.curToken,
+			Operator: ",",
+		}
+		p.nextToken()
+		column.Right = p.parseExpression(precedence)
+		expression.Right = column
+	}
+
 	return expression
 }
 
@@ -882,13 +895,12 @@ func (p *Parser) parseAnonymousFunction() ast.Expression {
 	p.previousToken(1)
 	lit.Parameters = p.parseFunctionParameters()
 
-	if !p.expectPeek(token.ItemColon) {
-		return nil
+	// the return type is inferred if omitted
+	if p.peekTokenIs(token.ItemColon) {
+		p.nextToken()
+		lit.ReturnType = p.parseTypes()
 	}
 
-	// parse types
-	lit.ReturnType = p.parseTypes()
-
 	lit.Name = ""
 
 	if !p.expectPeek(token.ItemArrow) {
@@ -1036,13 +1048,12 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 
 	lit.Parameters = p.parseFunctionParameters()
 
-	if !p.expectPeek(token.ItemColon) {
-		return nil
+	// the return type is inferred if omitted
+	if p.peekTokenIs(token.ItemColon) {
+		p.nextToken()
+		lit.ReturnType = p.parseTypes()
 	}
 
-	// parse types
-	lit.ReturnType = p.parseTypes()
-
 	// we could be in @generic which does not expect a body
 	if !p.peekTokenIs(token.ItemLeftCurly) {
 		return lit
@@ -1075,13 +1086,12 @@ func (p *Parser) parseFunctionParameters() []*ast.Parameter {
 
 		parameter := &ast.Parameter{Token: p.curToken, Name: p.curToken.Value}
 
-		if !p.expectPeek(token.ItemColon) {
-			continue
+		// anonymous functions may omit types, they are inferred
+		if p.peekTokenIs(token.ItemColon) {
+			p.nextToken()
+			parameter.Type = p.parseTypes()
 		}
 
-		// parse types
-		parameter.Type = p.parseTypes()
-
 		// if we have an assign we parse a statement, the function default
 		if p.peekTokenIs(token.ItemAssign) {
 			p.nextToken()
@@ -1142,6 +1152,51 @@ func (p *Parser) parseDecoratorDefault() ast.Expression {
 	return dec
 }
 
+func (p *Parser) parseDecoratorExport() ast.Expression {
+	dec := &ast.DecoratorExport{
+		Token: p.curToken,
+	}
+
+	p.skipNewLine()
+	p.nextToken()
+
+	// exporting a type
+	if p.curTokenIs(token.ItemTypesDecl) {
+		dec.Type = p.parseTypeDeclaration()
+		return dec
+	}
+
+	// exporting a function or method
+	if p.curTokenIs(token.ItemFunction) {
+		dec.Func = p.parseFunctionLiteral()
+		return dec
+	}
+
+	// exporting another decorator, e.g.: @generic or @class
+	dec.Func = p.parseExpression(LOWEST)
+
+	return dec
+}
+
+func (p *Parser) parseDecoratorChecked() ast.Expression {
+	dec := &ast.DecoratorChecked{
+		Token: p.curToken,
+	}
+
+	p.skipNewLine()
+	p.nextToken()
+
+	if p.curTokenIs(token.ItemFunction) {
+		dec.Func = p.parseFunctionLiteral()
+		return dec
+	}
+
+	// checking a decorated function, e.g.: @export
+	dec.Func = p.parseExpression(LOWEST)
+
+	return dec
+}
+
 func (p *Parser) parseDecoratorFactor() ast.Expression {
 	dec := &ast.DecoratorFactor{
 		Token: p.curToken,
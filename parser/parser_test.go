@@ -474,6 +474,58 @@ apply_math((1, 2, 3), (t: int): int => {
 	fmt.Println(prog.String())
 }
 
+func TestInferredReturn(t *testing.T) {
+	fmt.Println("---------------------------------------------------------- inferred return")
+	code := `func add(x: int, y: int) {
+  return x + y
+}
+
+lapply(1..10, (z: int) => {
+  return z + 1
+})
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := New(l)
+
+	prog := p.Run()
+
+	if p.HasError() {
+		p.Errors().Print()
+		t.Fatal("failed to parse functions without return type")
+	}
+
+	fmt.Println(prog.String())
+}
+
+func TestUntypedParameters(t *testing.T) {
+	fmt.Println("---------------------------------------------------------- untyped parameters")
+	code := `lapply(1..10, (z) => {
+  return z + 1
+})
+
+Map((x, y = 2) => {
+  return x + y
+}, 1..3)
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := New(l)
+
+	prog := p.Run()
+
+	if p.HasError() {
+		p.Errors().Print()
+		t.Fatal("failed to parse parameters without type")
+	}
+
+	fmt.Println(prog.String())
+}
+
 func TestList(t *testing.T) {
 	fmt.Println("---------------------------------------------------------- list")
 	code := `
@@ -690,6 +742,8 @@ y[[1]] = 1
 
 let zz: string = ("hello|world", "hello|again")
 let z: char = strsplit(zz[2], "\\|")[[1]]
+
+let age: int = df[, "age"]
 `
 
 	l := lexer.NewTest(code)
@@ -4,55 +4,58 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/vapourlang/vapour/ast"
+	"github.com/vapourlang/vapour/cache"
 )
 
-type arg struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
+const FORMALS = "FORMALS"
+
+// R function serialising the formals of a function to JSON,
+// arguments without default are the empty symbol
+const formalsJSON = `quoted <- function(x) {
+  if (length(x) == 0) return(character(0))
+  x <- gsub("\\", "\\\\", x, fixed = TRUE)
+  paste0('"', gsub('"', '\\"', x, fixed = TRUE), '"')
 }
 
-type args []arg
-
-func GetFunctionArguments(pkg, operator, function string) *ast.FunctionLiteral {
-	obj := &ast.FunctionLiteral{}
-	function = pkg + operator + function
-
-	output, err := Callr(
-		fmt.Sprintf(`args <- as.list(args(%v))
-    if(length(args) == 0){
-		  cat("[]")
-			return()
-		}
-
-    N <- length(args) - 1L
-		args <- args[1:N]
-
-    json_string <- c()
-    for(i in seq_along(args)) {
-			arg_string <- paste0(
-				'{"name": "', names(args)[i], '", "value": "', deparse(args[i]), '"}'
-			)
-			json_string <- c(json_string, arg_string)
-		}
-
-		json_string <- paste0(json_string, collapse = ",")
-		cat(paste0("[", json_string, "]"))`,
-			function,
-		),
+formals_json <- function(fn) {
+  fmls <- if (is.primitive(fn)) formals(args(fn)) else formals(fn)
+
+  if (length(fmls) == 0) return("[]")
+
+  values <- vapply(seq_along(fmls), function(i) {
+    if (identical(fmls[[i]], quote(expr = ))) return("")
+    paste(deparse(fmls[[i]]), collapse = " ")
+  }, character(1))
+
+  json <- paste0('{"name":', quoted(names(fmls)), ',"value":', quoted(values), '}', collapse = ",")
+  paste0("[", json, "]")
+}`
+
+// Formals lists the arguments of pkg::fn, primitives
+// (e.g.: c) report those of args(), e.g.: `...`
+func (p *Process) Formals(pkg, operator, function string) ([]Formal, error) {
+	key := FORMALS + ":" + pkg + operator + function
+	formals, cached := cache.Get[[]Formal](p.Cache(), key)
+
+	if cached {
+		return formals, nil
+	}
+
+	output, err := p.worker.Call(
+		fmt.Sprintf("%v\ncat(formals_json(%v%v%v))", formalsJSON, pkg, operator, "`"+function+"`"),
 	)
 
 	if err != nil {
-		return obj
+		return formals, err
 	}
 
-	var args args
-
-	err = json.Unmarshal(output, &args)
+	err = json.Unmarshal(output, &formals)
 
 	if err != nil {
-		return obj
+		return formals, err
 	}
 
-	return obj
+	cache.Set(p.Cache(), key, pkg, formals)
+
+	return formals, nil
 }
@@ -0,0 +1,27 @@
+package r
+
+// Backend answers the questions the type checker asks of R,
+// Process asks a running R, Snapshot answers from a JSON file
+// so that tests and CI do not need R installed.
+type Backend interface {
+	LibPaths() ([]string, error)
+	BaseFunctions() ([]Package, error)
+	PackageIsInstalled(pkg string) (bool, error)
+	PackageHasFunction(pkg, operator, fn string) (bool, error)
+	Formals(pkg, operator, fn string) ([]Formal, error)
+	Exports(pkg string) (Exports, error)
+}
+
+// Formal is an argument of an R function, Value is
+// its deparsed default, empty if it has none.
+type Formal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+var process = NewProcess(worker)
+
+// Default returns the backend using the shared R worker.
+func Default() Backend {
+	return process
+}
@@ -15,18 +15,19 @@ type Package struct {
 const (
 	BASEPACKAGES = "BASEPACKAGES"
 	FUNCTION     = "FUNCTION"
+	PACKAGE      = "PACKAGE"
 )
 
-func ListBaseFunctions() ([]Package, error) {
-	c, ok := cache.Get(BASEPACKAGES)
+func (p *Process) BaseFunctions() ([]Package, error) {
+	c, ok := cache.Get[[]Package](p.Cache(), BASEPACKAGES)
 
 	if ok {
-		return c.([]Package), nil
+		return c, nil
 	}
 
 	var packages []Package
 
-	output, err := Callr(
+	output, err := p.worker.Call(
 		`base_packages = getOption('defaultPackages')
 		base_packages <- c(base_packages, "base")
 		pkgs <- lapply(base_packages, function (pkg){
@@ -52,21 +53,21 @@ func ListBaseFunctions() ([]Package, error) {
 		return packages, err
 	}
 
-	cache.Set(BASEPACKAGES, packages)
+	cache.Set(p.Cache(), BASEPACKAGES, "", packages)
 
 	return packages, err
 }
 
-func PackageHasFunction(pkg, operator, fn string) (bool, error) {
-	key := pkg + fn
-	c, ok := cache.Get(key)
+func (p *Process) PackageHasFunction(pkg, operator, fn string) (bool, error) {
+	key := FUNCTION + ":" + pkg + operator + fn
+	ok, cached := cache.Get[bool](p.Cache(), key)
 
-	if ok {
-		return c.(bool), nil
+	if cached {
+		return ok, nil
 	}
 
-	output, err := Callr(
-		fmt.Sprintf("res <- tryCatch(%v%v%v);cat(inherits(res, 'error'))", pkg, operator, fn),
+	output, err := p.worker.Call(
+		fmt.Sprintf("res <- tryCatch(%v%v%v, error = function(e) e);cat(inherits(res, 'error'))", pkg, operator, fn),
 	)
 
 	if err != nil {
@@ -75,20 +76,20 @@ func PackageHasFunction(pkg, operator, fn string) (bool, error) {
 
 	ok = string(output) == "FALSE"
 
-	cache.Set(key, ok)
+	cache.Set(p.Cache(), key, pkg, ok)
 
 	return ok, err
 }
 
-func PackageIsInstalled(pkg string) (bool, error) {
-	key := "package::" + pkg
-	c, ok := cache.Get(key)
+func (p *Process) PackageIsInstalled(pkg string) (bool, error) {
+	key := PACKAGE + ":" + pkg
+	ok, cached := cache.Get[bool](p.Cache(), key)
 
-	if ok {
-		return c.(bool), nil
+	if cached {
+		return ok, nil
 	}
 
-	output, err := Callr(
+	output, err := p.worker.Call(
 		fmt.Sprintf("res <- requireNamespace('%v');cat(res)", pkg),
 	)
 
@@ -98,7 +99,7 @@ func PackageIsInstalled(pkg string) (bool, error) {
 
 	ok = string(output) == "TRUE"
 
-	cache.Set(key, ok)
+	cache.Set(p.Cache(), key, pkg, ok)
 
 	return ok, err
 }
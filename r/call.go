@@ -2,6 +2,17 @@ package r
 
 import "os/exec"
 
+var worker = NewWorker(DefaultTimeout)
+
+// Close saves the cache of R queries and
+// stops the shared R worker process.
+func Close() {
+	process.Save()
+	worker.Close()
+}
+
+// Callr runs code in a new R process, use it for
+// long running commands, e.g.: devtools::check().
 func Callr(cmd string) ([]byte, error) {
 	out, err := exec.Command(
 		"R",
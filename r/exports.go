@@ -0,0 +1,81 @@
+package r
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Exports describes the interface of an installed package.
+type Exports struct {
+	Package   string     `json:"package"`
+	Version   string     `json:"version"`
+	Functions []Function `json:"functions"`
+	Methods   []S3Method `json:"methods"`
+	Classes   []string   `json:"classes"`
+}
+
+type Function struct {
+	Name    string   `json:"name"`
+	Formals []Formal `json:"formals"`
+}
+
+// S3Method is a method the package registers,
+// the generic may come from another package.
+type S3Method struct {
+	Generic string   `json:"generic"`
+	Class   string   `json:"class"`
+	Formals []Formal `json:"formals"`
+}
+
+// Exports lists the exported functions of pkg, the S3 methods
+// it registers, and the S3 and S4 classes it defines.
+func (p *Process) Exports(pkg string) (Exports, error) {
+	var exports Exports
+
+	output, err := p.worker.Call(
+		fmt.Sprintf(`%v
+		pkg <- "%v"
+		ns <- asNamespace(pkg)
+
+		fns <- c()
+		for (name in sort(getNamespaceExports(pkg))) {
+			obj <- tryCatch(getExportedValue(pkg, name), error = function(e) NULL)
+			if (!is.function(obj)) next
+			fns <- c(fns, paste0('{"name":', quoted(name), ',"formals":', formals_json(obj), '}'))
+		}
+
+		s3 <- getNamespaceInfo(ns, "S3methods")
+		methods <- c()
+		for (i in seq_len(nrow(s3))) {
+			name <- s3[i, 3]
+			if (is.na(name)) name <- paste(s3[i, 1], s3[i, 2], sep = ".")
+			fn <- get0(name, envir = ns)
+			if (!is.function(fn)) next
+			methods <- c(methods, paste0(
+				'{"generic":', quoted(s3[i, 1]), ',"class":', quoted(s3[i, 2]),
+				',"formals":', formals_json(fn), '}'
+			))
+		}
+
+		classes <- sort(unique(c(s3[, 2], methods::getClasses(ns))))
+
+		cat(paste0(
+			'{"package":', quoted(pkg),
+			',"version":', quoted(as.character(utils::packageVersion(pkg))),
+			',"functions":[', paste(fns, collapse = ","), ']',
+			',"methods":[', paste(methods, collapse = ","), ']',
+			',"classes":[', paste(quoted(classes), collapse = ","), ']}'
+		))`,
+			formalsJSON,
+			pkg,
+		),
+	)
+
+	if err != nil {
+		return exports, err
+	}
+
+	err = json.Unmarshal(output, &exports)
+
+	return exports, err
+}
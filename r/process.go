@@ -0,0 +1,104 @@
+package r
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/vapourlang/vapour/cache"
+)
+
+// Process is the Backend querying R through a Worker,
+// answers are cached on disk for the R installation.
+type Process struct {
+	worker  *Worker
+	once    sync.Once
+	cache   *cache.Cache
+	library []string
+	err     error
+}
+
+func NewProcess(w *Worker) *Process {
+	return &Process{
+		worker: w,
+	}
+}
+
+// the cache is keyed by the R version and library paths,
+// which we fetch in a single call on first use
+func (p *Process) open() {
+	p.once.Do(func() {
+		p.cache = cache.New()
+
+		output, err := p.worker.Call(`cat(R.version.string, .libPaths(), sep = "\n")`)
+
+		if err != nil {
+			p.err = err
+			return
+		}
+
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		p.library = lines[1:]
+
+		c, err := cache.Open(lines[0], p.library, func(pkg string) string {
+			return PackageVersion(p.library, pkg)
+		})
+
+		// we can still work without the disk cache
+		if err != nil {
+			return
+		}
+
+		p.cache = c
+	})
+}
+
+// Cache returns the cache of the R installation, it is
+// only persisted if R could be queried.
+func (p *Process) Cache() *cache.Cache {
+	p.open()
+	return p.cache
+}
+
+// Save writes the answers cached during the run to disk
+func (p *Process) Save() error {
+	if p.cache == nil {
+		return nil
+	}
+
+	return p.cache.Save()
+}
+
+func (p *Process) LibPaths() ([]string, error) {
+	p.open()
+	return p.library, p.err
+}
+
+// PackageVersion reads the installed version of pkg from its
+// DESCRIPTION file rather than asking R,
+// empty if the package is not installed
+func PackageVersion(library []string, pkg string) string {
+	for _, lib := range library {
+		f, err := os.Open(filepath.Join(lib, pkg, "DESCRIPTION"))
+
+		if err != nil {
+			continue
+		}
+
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "Version:") {
+				return strings.TrimSpace(strings.TrimPrefix(line, "Version:"))
+			}
+		}
+
+		return ""
+	}
+
+	return ""
+}
@@ -0,0 +1,119 @@
+package r
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Snapshot is a Backend answering from memory, e.g.:
+//
+//	{
+//	  "library": ["/usr/lib/R/library"],
+//	  "base": [{"name": "base", "functions": ["print", "paste"]}],
+//	  "packages": {"dplyr": ["filter", "mutate"]},
+//	  "formals": {"base::print": [{"name": "x", "value": ""}, {"name": "...", "value": ""}]}
+//	}
+//
+// formals are keyed by package and function, e.g.: dplyr::filter,
+// an empty value is an argument without default, "exports"
+// optionally describes packages for stubgen, see Exports.
+type Snapshot struct {
+	Library    []string            `json:"library"`
+	Base       []Package           `json:"base"`
+	Packages   map[string][]string `json:"packages"`
+	Arguments  map[string][]Formal `json:"formals"`
+	Interfaces map[string]Exports  `json:"exports"`
+}
+
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		Packages:   make(map[string][]string),
+		Arguments:  make(map[string][]Formal),
+		Interfaces: make(map[string]Exports),
+	}
+}
+
+func LoadSnapshot(path string) (*Snapshot, error) {
+	s := NewSnapshot()
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return s, err
+	}
+
+	err = json.Unmarshal(data, s)
+
+	return s, err
+}
+
+func (s *Snapshot) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func (s *Snapshot) LibPaths() ([]string, error) {
+	return s.Library, nil
+}
+
+func (s *Snapshot) BaseFunctions() ([]Package, error) {
+	return s.Base, nil
+}
+
+func (s *Snapshot) PackageIsInstalled(pkg string) (bool, error) {
+	_, ok := s.Packages[pkg]
+	return ok, nil
+}
+
+func (s *Snapshot) PackageHasFunction(pkg, operator, fn string) (bool, error) {
+	for _, f := range s.Packages[pkg] {
+		if f == fn {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *Snapshot) Formals(pkg, operator, fn string) ([]Formal, error) {
+	key := pkg + "::" + fn
+	formals, ok := s.Arguments[key]
+
+	if !ok {
+		return formals, fmt.Errorf("no formals for `%v` in snapshot", key)
+	}
+
+	return formals, nil
+}
+
+// Exports of packages missing from "exports"
+// are derived from "packages" and "formals"
+func (s *Snapshot) Exports(pkg string) (Exports, error) {
+	exports, ok := s.Interfaces[pkg]
+
+	if ok {
+		return exports, nil
+	}
+
+	fns, ok := s.Packages[pkg]
+
+	if !ok {
+		return exports, fmt.Errorf("package `%v` not in snapshot", pkg)
+	}
+
+	exports.Package = pkg
+	for _, fn := range fns {
+		exports.Functions = append(exports.Functions, Function{
+			Name:    fn,
+			Formals: s.Arguments[pkg+"::"+fn],
+		})
+	}
+
+	return exports, nil
+}
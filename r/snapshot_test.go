@@ -0,0 +1,45 @@
+package r
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "r.json")
+
+	s := NewSnapshot()
+	s.Packages["dplyr"] = []string{"filter"}
+	s.Arguments["dplyr::filter"] = []Formal{{Name: ".data"}, {Name: "..."}}
+
+	err := s.Write(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var backend Backend
+	backend, err = LoadSnapshot(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, _ := backend.PackageHasFunction("dplyr", "::", "filter")
+
+	if !ok {
+		t.Fatal("expected dplyr::filter to exist")
+	}
+
+	ok, _ = backend.PackageIsInstalled("data.table")
+
+	if ok {
+		t.Fatal("expected data.table not to be installed")
+	}
+
+	formals, err := backend.Formals("dplyr", "::", "filter")
+
+	if err != nil || len(formals) != 2 {
+		t.Fatalf("expected 2 formals, got %v, %v", formals, err)
+	}
+}
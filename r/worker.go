@@ -0,0 +1,232 @@
+package r
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is how long we wait on R to answer a request,
+// listing base functions on a cold start is the slowest.
+const DefaultTimeout = 30 * time.Second
+
+// the worker reads requests from stdin:
+//
+//	<id> <number of lines>
+//	<lines of code>
+//
+// and writes responses to stdout:
+//
+//	VAPOUR <id> <ok|error> <number of lines>
+//	<lines of output>
+//
+// code runs in a function so it may return() early,
+// what it prints with cat() is the output.
+const workerLoop = `con <- file("stdin", open = "r")
+repeat {
+  header <- readLines(con, n = 1L)
+  if (length(header) == 0L) break
+  header <- strsplit(header, " ", fixed = TRUE)[[1]]
+  code <- readLines(con, n = as.integer(header[2]))
+  status <- "ok"
+  out <- tryCatch(
+    {
+      fn <- eval(parse(text = c("function() {", code, "}")), globalenv())
+      utils::capture.output(invisible(fn()))
+    },
+    error = function(e) {
+      status <<- "error"
+      gsub("\n", " ", conditionMessage(e))
+    }
+  )
+  cat("\nVAPOUR", header[1], status, length(out), "\n")
+  writeLines(out)
+  flush(stdout())
+}`
+
+var errCrashed = errors.New("R worker stopped unexpectedly")
+
+// Worker is a long-lived R process answering requests,
+// it is safe for concurrent use, requests are serialised.
+type Worker struct {
+	mu      sync.Mutex
+	command string
+	args    []string
+	timeout time.Duration
+	id      int
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string
+}
+
+func NewWorker(timeout time.Duration) *Worker {
+	return &Worker{
+		command: "R",
+		args:    []string{"-s", "-e", workerLoop},
+		timeout: timeout,
+	}
+}
+
+// Call evaluates code in the worker and returns what it printed,
+// the process is (re)started if it is not running.
+func (w *Worker) Call(code string) ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	out, err := w.call(code)
+
+	// R crashed, e.g.: a package segfaulted, try once more
+	if errors.Is(err, errCrashed) {
+		w.stop()
+		out, err = w.call(code)
+	}
+
+	return out, err
+}
+
+// Close stops the R process, the next call starts a new one.
+func (w *Worker) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stop()
+}
+
+func (w *Worker) start() error {
+	cmd := exec.Command(w.command, w.args...)
+
+	stdin, err := cmd.StdinPipe()
+
+	if err != nil {
+		return err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Start()
+
+	if err != nil {
+		return err
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	w.cmd = cmd
+	w.stdin = stdin
+	w.lines = lines
+
+	return nil
+}
+
+func (w *Worker) stop() {
+	if w.cmd == nil {
+		return
+	}
+
+	w.stdin.Close()
+	w.cmd.Process.Kill()
+
+	cmd := w.cmd
+	lines := w.lines
+	go func() {
+		// unblock the reader so it can exit
+		for range lines {
+		}
+		cmd.Wait()
+	}()
+
+	w.cmd = nil
+	w.stdin = nil
+	w.lines = nil
+}
+
+func (w *Worker) call(code string) ([]byte, error) {
+	if w.cmd == nil {
+		err := w.start()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.id++
+	id := strconv.Itoa(w.id)
+	code = strings.TrimRight(code, "\n")
+
+	request := fmt.Sprintf("%v %v\n%v\n", id, strings.Count(code, "\n")+1, code)
+	_, err := io.WriteString(w.stdin, request)
+
+	if err != nil {
+		return nil, errCrashed
+	}
+
+	timer := time.NewTimer(w.timeout)
+	defer timer.Stop()
+
+	for {
+		line, err := w.next(timer)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// anything else R printed, e.g.: from .Rprofile
+		fields := strings.Fields(line)
+		if len(fields) != 4 || fields[0] != "VAPOUR" || fields[1] != id {
+			continue
+		}
+
+		n, err := strconv.Atoi(fields[3])
+
+		if err != nil {
+			return nil, fmt.Errorf("malformed response from R: %v", line)
+		}
+
+		output := make([]string, n)
+		for i := range output {
+			output[i], err = w.next(timer)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if fields[2] == "error" {
+			return nil, fmt.Errorf("R error: %v", strings.Join(output, "\n"))
+		}
+
+		return []byte(strings.Join(output, "\n")), nil
+	}
+}
+
+func (w *Worker) next(timer *time.Timer) (string, error) {
+	select {
+	case line, ok := <-w.lines:
+		if !ok {
+			return "", errCrashed
+		}
+		return line, nil
+	case <-timer.C:
+		// R may be stuck, the next call starts afresh
+		w.stop()
+		return "", fmt.Errorf("R did not respond within %v", w.timeout)
+	}
+}
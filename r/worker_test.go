@@ -0,0 +1,102 @@
+package r
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fake R answering requests with the code it received,
+// "sleep" hangs and "crash" exits
+const fakeR = `#!/bin/sh
+while read id n; do
+  out=""
+  i=0
+  while [ $i -lt $n ]; do
+    read line
+    case "$line" in
+      sleep) sleep 5 ;;
+      crash) exit 1 ;;
+    esac
+    out="$line"
+    i=$((i + 1))
+  done
+  echo "noise"
+  echo "VAPOUR $id ok 1"
+  echo "$out"
+done
+`
+
+func newFakeWorker(t *testing.T, timeout time.Duration) *Worker {
+	path := filepath.Join(t.TempDir(), "R")
+	err := os.WriteFile(path, []byte(fakeR), 0755)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWorker(timeout)
+	w.command = path
+	w.args = []string{}
+
+	t.Cleanup(w.Close)
+
+	return w
+}
+
+func TestWorker(t *testing.T) {
+	w := newFakeWorker(t, time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out, err := w.Call("first\nsecond")
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if string(out) != "second" {
+				t.Errorf("expected `second`, got `%v`", string(out))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWorkerTimeout(t *testing.T) {
+	w := newFakeWorker(t, 100*time.Millisecond)
+
+	_, err := w.Call("sleep")
+
+	if err == nil {
+		t.Fatal("expected timeout")
+	}
+
+	out, err := w.Call("after")
+
+	if err != nil || string(out) != "after" {
+		t.Fatalf("expected worker to restart, got `%v`, %v", string(out), err)
+	}
+}
+
+func TestWorkerCrash(t *testing.T) {
+	w := newFakeWorker(t, time.Second)
+
+	_, err := w.Call("crash")
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	out, err := w.Call("after")
+
+	if err != nil || string(out) != "after" {
+		t.Fatalf("expected worker to restart, got `%v`, %v", string(out), err)
+	}
+}
@@ -5,37 +5,95 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/vapourlang/vapour/cache"
 	"github.com/vapourlang/vapour/cli"
 	"github.com/vapourlang/vapour/config"
 	"github.com/vapourlang/vapour/devtools"
 	"github.com/vapourlang/vapour/environment"
 	"github.com/vapourlang/vapour/lsp"
 	"github.com/vapourlang/vapour/r"
+	"github.com/vapourlang/vapour/sourcemap"
 )
 
-func run(code string) {
+// we source a file, rather than evaluate the code,
+// so R reports lines we can map back to vapour files
+func run(code string, smap *sourcemap.SourceMap) {
+	dir, err := os.MkdirTemp("", "vapour")
+
+	if err != nil {
+		log.Fatal("Failed to run")
+	}
+
+	path := filepath.Join(dir, filepath.Base(smap.File))
+	err = os.WriteFile(path, []byte(code), 0644)
+
+	if err != nil {
+		os.RemoveAll(dir)
+		log.Fatal("Failed to run")
+	}
+
 	cmd := exec.Command(
 		"R",
 		"--no-save",
 		"--slave",
 		"-e",
-		code,
+		fmt.Sprintf(`options(show.error.locations = TRUE)
+		withCallingHandlers(
+			source(%q, keep.source = TRUE, print.eval = TRUE),
+			error = function(e) {
+				for (call in rev(sys.calls())) {
+					ref <- attr(call, "srcref")
+					if (is.null(ref)) next
+					cat(sprintf("  at %%s#%%d\n", basename(attr(ref, "srcfile")$filename), ref[1L]), file = stderr())
+				}
+			}
+		)`, path),
 	)
 
 	output, err := cmd.CombinedOutput()
+	os.RemoveAll(dir)
+
+	fmt.Println(smap.Rewrite(string(output)))
 
 	if err != nil {
 		log.Fatal("Failed to run")
 	}
-
-	fmt.Println(string(output))
 }
 
 func (v *vapour) Run(args cli.CLI) {
 	v.config = config.ReadConfig()
 
-	environment.SetLibrary(r.LibPath())
+	if *args.Cache != "" {
+		manageCache(*args.Cache)
+		return
+	}
+
+	v.backend = r.Default()
+
+	if *args.RSnapshot != "" {
+		snapshot, err := r.LoadSnapshot(*args.RSnapshot)
+
+		if err != nil {
+			log.Fatalf("Failed to read R snapshot: %v", err.Error())
+		}
+
+		v.backend = snapshot
+	}
+
+	library, err := v.backend.LibPaths()
+
+	if err != nil {
+		fmt.Printf("failed to fetch R library paths: %v\n", err.Error())
+	}
+
+	environment.SetLibrary(library)
+
+	// project stubs, e.g.: written by stubgen, take precedence
+	environment.SetStubPaths(append([]string{"stubs"}, v.config.Stubs...))
 
 	if *args.Indir != "" {
 		ok := v.transpile(args)
@@ -55,7 +113,7 @@ func (v *vapour) Run(args cli.CLI) {
 	}
 
 	if *args.LSP {
-		lsp.Run(v.config, *args.TCP, *args.Port)
+		lsp.Run(v.config, v.backend, *args.TCP, *args.Port)
 		return
 	}
 
@@ -64,3 +122,50 @@ func (v *vapour) Run(args cli.CLI) {
 		return
 	}
 }
+
+func manageCache(cmd string) {
+	switch cmd {
+	case "show":
+		caches, err := cache.List()
+
+		if err != nil {
+			log.Fatalf("Failed to read cache: %v", err.Error())
+		}
+
+		if len(caches) == 0 {
+			fmt.Println("cache is empty")
+			return
+		}
+
+		for _, c := range caches {
+			fmt.Printf("%v%v%v\n", cli.Bold, c.Path(), cli.Reset)
+			fmt.Printf("  %v\n", c.R)
+			fmt.Printf("  library: %v\n", strings.Join(c.Library, ", "))
+
+			counts := c.Packages()
+			var pkgs []string
+			for pkg := range counts {
+				pkgs = append(pkgs, pkg)
+			}
+			sort.Strings(pkgs)
+
+			for _, pkg := range pkgs {
+				name := pkg
+				if name == "" {
+					name = "base"
+				}
+				fmt.Printf("  %v: %v entries\n", name, counts[pkg])
+			}
+		}
+	case "clear":
+		err := cache.Clear()
+
+		if err != nil {
+			log.Fatalf("Failed to clear cache: %v", err.Error())
+		}
+
+		fmt.Println(cli.Green + "✓" + cli.Reset + " cache cleared")
+	default:
+		log.Fatalf("Unknown cache command `%v`, expects `show` or `clear`", cmd)
+	}
+}
@@ -0,0 +1,123 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// lines and columns are 1-based, as reported by R
+type Mapping struct {
+	Line         int    `json:"line"`
+	Column       int    `json:"column"`
+	Source       string `json:"source"`
+	SourceLine   int    `json:"sourceLine"`
+	SourceColumn int    `json:"sourceColumn"`
+}
+
+type SourceMap struct {
+	Version  int       `json:"version"`
+	File     string    `json:"file"`
+	Mappings []Mapping `json:"mappings"`
+}
+
+func New(file string) *SourceMap {
+	return &SourceMap{
+		Version: 1,
+		File:    file,
+	}
+}
+
+func (s *SourceMap) Add(m Mapping) {
+	s.Mappings = append(s.Mappings, m)
+}
+
+// Shift moves the mappings down by n lines,
+// e.g.: when a header is prepended to the code.
+func (s *SourceMap) Shift(n int) {
+	for i := range s.Mappings {
+		s.Mappings[i].Line += n
+	}
+}
+
+// Lookup returns the mapping closest to, but not after,
+// the start of the given line of generated code.
+func (s *SourceMap) Lookup(line int) (Mapping, bool) {
+	var found Mapping
+	ok := false
+
+	for _, m := range s.Mappings {
+		if m.Line > line {
+			continue
+		}
+
+		if !ok || m.Line > found.Line || (m.Line == found.Line && m.Column < found.Column) {
+			found = m
+			ok = true
+		}
+	}
+
+	return found, ok
+}
+
+// Rewrite replaces references to generated lines in R
+// output with their position in the vapour source, e.g.:
+// "(from vapour.R#12)" or "vapour.R:12:3".
+func (s *SourceMap) Rewrite(output string) string {
+	name := regexp.QuoteMeta(filepath.Base(s.File))
+
+	srcref := regexp.MustCompile(name + `#(\d+)`)
+	output = srcref.ReplaceAllStringFunc(output, func(match string) string {
+		return s.replace(match, srcref.FindStringSubmatch(match)[1])
+	})
+
+	parse := regexp.MustCompile(name + `:(\d+):(\d+)`)
+	output = parse.ReplaceAllStringFunc(output, func(match string) string {
+		return s.replace(match, parse.FindStringSubmatch(match)[1])
+	})
+
+	return output
+}
+
+func (s *SourceMap) replace(match, line string) string {
+	n, err := strconv.Atoi(line)
+
+	if err != nil {
+		return match
+	}
+
+	m, ok := s.Lookup(n)
+
+	if !ok {
+		return match
+	}
+
+	return fmt.Sprintf("%v:%v:%v", m.Source, m.SourceLine, m.SourceColumn)
+}
+
+func (s *SourceMap) Write(path string) error {
+	data, err := json.Marshal(s)
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func Read(path string) (*SourceMap, error) {
+	s := &SourceMap{}
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return s, err
+	}
+
+	err = json.Unmarshal(data, s)
+
+	return s, err
+}
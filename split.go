@@ -0,0 +1,350 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vapourlang/vapour/ast"
+	"github.com/vapourlang/vapour/cli"
+	"github.com/vapourlang/vapour/transpiler"
+)
+
+// how files from -indir are written to -outdir
+const (
+	layoutSingle = "single"
+	layoutTree   = "tree"
+	layoutFlat   = "flat"
+)
+
+func (v *vapour) writeSingle(conf cli.CLI, trans *transpiler.Transpiler) {
+	path := *conf.Outdir + "/" + *conf.Outfile
+
+	writeFile(path, addHeader(trans.GetCode()))
+
+	if *conf.SourceMap {
+		writeSourceMap(trans.GetSourceMap(path), path)
+	}
+}
+
+// one R file per vapour file
+func (v *vapour) writeFiles(conf cli.CLI, trans *transpiler.Transpiler, prog *ast.Program) {
+	if *conf.Layout != layoutTree && *conf.Layout != layoutFlat {
+		log.Fatalf("Unknown layout `%v`, expects `single`, `tree`, or `flat`", *conf.Layout)
+	}
+
+	// R only loads the files directly under R/
+	if _, ok := packageRoot(*conf.Outdir); ok && *conf.Layout == layoutTree {
+		log.Fatalf("R does not load subdirectories of %v, use -layout flat", *conf.Outdir)
+	}
+
+	paths := make(map[string]string)
+	written := make(map[string]bool)
+	for _, file := range trans.GetFiles() {
+		path := outputPath(conf, file)
+
+		if written[path] {
+			log.Fatalf("Multiple vapour files transpile to %v, use another -separator", path)
+		}
+
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+
+		if err != nil {
+			log.Fatalf("Failed to create output directory: %v", err.Error())
+		}
+
+		writeFile(path, addHeader(trans.GetFileCode(file)))
+
+		if *conf.SourceMap {
+			writeSourceMap(trans.GetFileSourceMap(file, path), path)
+		}
+
+		paths[file] = path
+		written[path] = true
+	}
+
+	removeStale(written)
+
+	// R files the user wrote come first, they cannot
+	// depend on vapour code at load time
+	order := userFiles(*conf.Outdir, written)
+
+	for _, file := range collate(prog, trans.GetFiles()) {
+		rel, err := filepath.Rel(*conf.Outdir, paths[file])
+
+		if err != nil {
+			rel = paths[file]
+		}
+
+		order = append(order, filepath.ToSlash(rel))
+	}
+
+	root, ok := packageRoot(*conf.Outdir)
+	description := filepath.Join(root, "DESCRIPTION")
+
+	if _, err := os.Stat(description); err != nil || !ok {
+		fmt.Println(collateField(order))
+		return
+	}
+
+	err := writeCollate(description, order)
+
+	if err != nil {
+		log.Fatalf("Failed to write Collate to DESCRIPTION: %v", err.Error())
+	}
+}
+
+func writeFile(path, content string) {
+	f, err := os.Create(path)
+
+	if err != nil {
+		log.Fatalf("Failed to create output file: %v", err.Error())
+	}
+
+	defer f.Close()
+
+	_, err = f.WriteString(content)
+
+	if err != nil {
+		log.Fatalf("Failed to write output file: %v", err.Error())
+	}
+}
+
+// e.g.: models/user.vp is written to R/models/user.R
+// with the tree layout and to R/models-user.R when flat
+func outputPath(conf cli.CLI, file string) string {
+	rel, err := filepath.Rel(*conf.Indir, file)
+
+	if err != nil {
+		rel = filepath.Base(file)
+	}
+
+	rel = strings.TrimSuffix(rel, filepath.Ext(rel)) + ".R"
+
+	if *conf.Layout == layoutFlat {
+		rel = strings.ReplaceAll(filepath.ToSlash(rel), "/", *conf.Separator)
+	}
+
+	return filepath.Join(*conf.Outdir, rel)
+}
+
+func isGenerated(path string) bool {
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(string(content), addHeader(""))
+}
+
+// generated files whose vapour file was deleted or renamed,
+// only next to the files we wrote so the output directory
+// can be shared, files without our header are never removed
+func removeStale(written map[string]bool) {
+	dirs := make(map[string]bool)
+	for path := range written {
+		dirs[filepath.Dir(path)] = true
+	}
+
+	for dir := range dirs {
+		entries, err := os.ReadDir(dir)
+
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+
+			if entry.IsDir() || filepath.Ext(path) != ".R" {
+				continue
+			}
+
+			if written[path] || !isGenerated(path) {
+				continue
+			}
+
+			os.Remove(path)
+			os.Remove(path + ".map")
+		}
+	}
+}
+
+func userFiles(dir string, written map[string]bool) []string {
+	var files []string
+
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return files
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+
+		if entry.IsDir() || filepath.Ext(path) != ".R" || written[path] {
+			continue
+		}
+
+		files = append(files, entry.Name())
+	}
+
+	sort.Strings(files)
+
+	return files
+}
+
+// collate orders files so that those defining functions and
+// variables come before those using them, cycles are
+// broken in the original order of the files.
+func collate(prog *ast.Program, files []string) []string {
+	defined := make(map[string][]string)
+	used := make(map[string]map[string]bool)
+
+	for _, statement := range prog.Statements {
+		file := statement.Item().File
+
+		for _, name := range definitions(statement) {
+			defined[name] = append(defined[name], file)
+		}
+
+		if used[file] == nil {
+			used[file] = make(map[string]bool)
+		}
+
+		ast.Inspect(statement, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.Identifier:
+				used[file][n.Value] = true
+			case *ast.CallExpression:
+				used[file][n.Name] = true
+			}
+			return true
+		})
+	}
+
+	dependencies := make(map[string]map[string]bool)
+	for file, names := range used {
+		dependencies[file] = make(map[string]bool)
+		for name := range names {
+			for _, dep := range defined[name] {
+				if dep == file {
+					continue
+				}
+				dependencies[file][dep] = true
+			}
+		}
+	}
+
+	var order []string
+	placed := make(map[string]bool)
+	for len(order) < len(files) {
+		next := ""
+		for _, file := range files {
+			if placed[file] {
+				continue
+			}
+
+			if next == "" {
+				next = file
+			}
+
+			if ready(dependencies[file], placed) {
+				next = file
+				break
+			}
+		}
+
+		placed[next] = true
+		order = append(order, next)
+	}
+
+	return order
+}
+
+func ready(dependencies, placed map[string]bool) bool {
+	for dep := range dependencies {
+		if !placed[dep] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// names a top-level statement defines
+func definitions(statement ast.Statement) []string {
+	switch s := statement.(type) {
+	case *ast.LetStatement:
+		return []string{s.Name}
+	case *ast.ConstStatement:
+		return []string{s.Name}
+	case *ast.ExpressionStatement:
+		return definedFunction(s.Expression)
+	}
+
+	return []string{}
+}
+
+func definedFunction(node ast.Expression) []string {
+	switch n := node.(type) {
+	case *ast.FunctionLiteral:
+		if n.Name == "" {
+			return []string{}
+		}
+		return []string{n.Name}
+	case *ast.DecoratorGeneric:
+		return definedFunction(n.Func)
+	case *ast.DecoratorDefault:
+		return definedFunction(n.Func)
+	case *ast.DecoratorExport:
+		return definedFunction(n.Func)
+	case *ast.DecoratorChecked:
+		return definedFunction(n.Func)
+	}
+
+	return []string{}
+}
+
+func collateField(files []string) string {
+	field := "Collate:"
+	for _, file := range files {
+		field += "\n    '" + file + "'"
+	}
+
+	return field
+}
+
+// replaces the Collate field of the DESCRIPTION file,
+// continuation lines of a field are indented
+func writeCollate(path string, files []string) error {
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	inCollate := false
+	for _, line := range strings.Split(strings.TrimRight(string(content), "\n"), "\n") {
+		if strings.HasPrefix(line, "Collate:") {
+			inCollate = true
+			continue
+		}
+
+		if inCollate && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			continue
+		}
+
+		inCollate = false
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, collateField(files))
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
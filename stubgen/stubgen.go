@@ -0,0 +1,136 @@
+package stubgen
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/vapourlang/vapour/r"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z.][A-Za-z0-9._]*$`)
+var number = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?L?$`)
+var str = regexp.MustCompile(`^"([^"\\]|\\.)*"$`)
+
+// words the lexer reserves, they cannot name functions or arguments
+var keywords = []string{
+	"if", "else", "return", "for", "while", "repeat", "next", "break",
+	"func", "in", "let", "const", "type", "defer", "nan", "inf",
+	"TRUE", "FALSE", "true", "false", "NULL", "NA",
+	"na_int", "na_char", "na_real", "na_complex",
+}
+
+// Generate writes a starter type file for an installed package,
+// everything is typed any for the user to curate.
+func Generate(exports r.Exports) string {
+	var code []string
+	var skipped []string
+
+	pkg := exports.Package
+	if exports.Version != "" {
+		pkg += " " + exports.Version
+	}
+
+	code = append(
+		code,
+		"# types for "+pkg+" generated by vapour stubgen",
+		"# arguments and values are typed any, complex defaults are NULL",
+	)
+
+	var classes []string
+	for _, c := range exports.Classes {
+		if !isName(c) {
+			skipped = append(skipped, c)
+			continue
+		}
+
+		classes = append(classes, "type "+c+": object {}")
+	}
+
+	var functions []string
+	for _, fn := range exports.Functions {
+		if !isName(fn.Name) {
+			skipped = append(skipped, fn.Name)
+			continue
+		}
+
+		functions = append(functions, "func "+fn.Name+"("+parameters(fn.Formals)+"): any")
+	}
+
+	var methods []string
+	for _, m := range exports.Methods {
+		if !isName(m.Generic) || !isName(m.Class) || len(m.Formals) == 0 || !isName(m.Formals[0].Name) {
+			skipped = append(skipped, m.Generic+"."+m.Class)
+			continue
+		}
+
+		methods = append(
+			methods,
+			"func ("+m.Formals[0].Name+": "+m.Class+") "+m.Generic+"("+parameters(m.Formals[1:])+"): any",
+		)
+	}
+
+	if len(skipped) > 0 {
+		code = append(code, "# not valid vapour names: "+strings.Join(skipped, ", "))
+	}
+
+	for _, section := range [][]string{classes, functions, methods} {
+		if len(section) == 0 {
+			continue
+		}
+
+		code = append(code, "")
+		code = append(code, section...)
+	}
+
+	return strings.Join(code, "\n") + "\n"
+}
+
+// arguments we cannot name are passed through ...
+func parameters(formals []r.Formal) string {
+	var params []string
+	dots := false
+	for _, f := range formals {
+		if f.Name == "..." || !isName(f.Name) {
+			if !dots {
+				params = append(params, "...: any")
+			}
+			dots = true
+			continue
+		}
+
+		params = append(params, f.Name+": any"+defaultValue(f.Value))
+	}
+
+	return strings.Join(params, ", ")
+}
+
+func defaultValue(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	switch {
+	case value == "TRUE" || value == "FALSE" || value == "NULL" || value == "NA":
+	case number.MatchString(value):
+		value = strings.TrimSuffix(value, "L")
+	case str.MatchString(value):
+	default:
+		value = "NULL"
+	}
+
+	return " = " + value
+}
+
+func isName(name string) bool {
+	if !identifier.MatchString(name) {
+		return false
+	}
+
+	for _, k := range keywords {
+		if name == k {
+			return false
+		}
+	}
+
+	return true
+}
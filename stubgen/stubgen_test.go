@@ -0,0 +1,99 @@
+package stubgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vapourlang/vapour/ast"
+	"github.com/vapourlang/vapour/lexer"
+	"github.com/vapourlang/vapour/parser"
+	"github.com/vapourlang/vapour/r"
+)
+
+func TestGenerate(t *testing.T) {
+	exports := r.Exports{
+		Package: "dplyr",
+		Version: "1.1.4",
+		Functions: []r.Function{
+			{
+				Name: "filter",
+				Formals: []r.Formal{
+					{Name: ".data"},
+					{Name: "..."},
+					{Name: ".by", Value: "NULL"},
+					{Name: ".preserve", Value: "FALSE"},
+				},
+			},
+			{
+				Name: "slice_head",
+				Formals: []r.Formal{
+					{Name: ".data"},
+					{Name: "..."},
+					{Name: "n", Value: "5L"},
+					{Name: "prop", Value: "c(1, 2)"},
+					{Name: "type", Value: "\"rows\""},
+				},
+			},
+			{Name: "%>%"},
+		},
+		Methods: []r.S3Method{
+			{
+				Generic: "print",
+				Class:   "grouped_df",
+				Formals: []r.Formal{{Name: "x"}, {Name: "..."}},
+			},
+		},
+		Classes: []string{"grouped_df"},
+	}
+
+	code := Generate(exports)
+
+	expected := `# types for dplyr 1.1.4 generated by vapour stubgen
+# arguments and values are typed any, complex defaults are NULL
+# not valid vapour names: %>%
+
+type grouped_df: object {}
+
+func filter(.data: any, ...: any, .by: any = NULL, .preserve: any = FALSE): any
+func slice_head(.data: any, ...: any, n: any = 5, prop: any = NULL): any
+
+func (x: grouped_df) print(...: any): any
+`
+
+	if code != expected {
+		t.Fatalf("expected:\n%v\ngot:\n%v", expected, code)
+	}
+
+	l := lexer.NewCode("types.vp", code)
+	l.Run()
+
+	if l.HasError() {
+		l.Errors().Print()
+		t.Fatal("lexer errored")
+	}
+
+	p := parser.New(l)
+	prog := p.Run()
+
+	if p.HasError() {
+		p.Errors().Print()
+		t.Fatal("parser errored")
+	}
+
+	var names []string
+	for _, s := range prog.Statements {
+		switch n := s.(type) {
+		case *ast.TypeStatement:
+			names = append(names, n.Name)
+		case *ast.ExpressionStatement:
+			fn, ok := n.Expression.(*ast.FunctionLiteral)
+			if ok {
+				names = append(names, fn.Name)
+			}
+		}
+	}
+
+	if strings.Join(names, ",") != "grouped_df,filter,slice_head,print" {
+		t.Fatalf("unexpected declarations: %v", names)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/vapourlang/vapour/cli"
+	"github.com/vapourlang/vapour/r"
+	"github.com/vapourlang/vapour/stubgen"
+)
+
+// vapour stubgen [-out path] <package>
+func (v *vapour) stubgen(args []string) {
+	flags := flag.NewFlagSet("stubgen", flag.ExitOnError)
+	out := flags.String("out", "", "Path of the type file to write (defaults to stubs/<package>/types.vp)")
+	snapshot := flags.String("r-snapshot", "", "Introspect the package from a JSON snapshot rather than running R")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: vapour stubgen [-out path] <package>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	pkg := flags.Arg(0)
+
+	var backend r.Backend = r.Default()
+	if *snapshot != "" {
+		s, err := r.LoadSnapshot(*snapshot)
+
+		if err != nil {
+			log.Fatalf("Failed to read R snapshot: %v", err.Error())
+		}
+
+		backend = s
+	}
+
+	exports, err := backend.Exports(pkg)
+
+	if err != nil {
+		log.Fatalf("Failed to introspect package `%v`: %v", pkg, err.Error())
+	}
+
+	path := *out
+	if path == "" {
+		path = filepath.Join("stubs", pkg, "types.vp")
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+
+	if err != nil {
+		log.Fatalf("Failed to create directory: %v", err.Error())
+	}
+
+	err = os.WriteFile(path, []byte(stubgen.Generate(exports)), 0644)
+
+	if err != nil {
+		log.Fatalf("Failed to write stubs: %v", err.Error())
+	}
+
+	fmt.Printf(
+		"%v✓%v wrote %v functions, %v methods, and %v classes to %v\n",
+		cli.Green,
+		cli.Reset,
+		len(exports.Functions),
+		len(exports.Methods),
+		len(exports.Classes),
+		path,
+	)
+}
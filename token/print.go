@@ -80,6 +80,8 @@ var ItemName = map[ItemType]string{
 	ItemDecoratorClass:    "decorator class",
 	ItemDecoratorGeneric:  "decorator generic",
 	ItemDecoratorDefault:  "decorator default",
+	ItemDecoratorExport:   "decorator export",
+	ItemDecoratorChecked:  "decorator checked",
 	ItemAttribute:         "attribute",
 	ItemObjList:           "object list",
 	ItemObjFunc:           "object function",
@@ -170,6 +170,8 @@ const (
 	ItemDecoratorDefault
 	ItemDecoratorMatrix
 	ItemDecoratorFactor
+	ItemDecoratorExport
+	ItemDecoratorChecked
 
 	// attribute
 	ItemAttribute
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/vapourlang/vapour/cli"
 	"github.com/vapourlang/vapour/lexer"
 	"github.com/vapourlang/vapour/parser"
+	"github.com/vapourlang/vapour/sourcemap"
 	"github.com/vapourlang/vapour/transpiler"
 	"github.com/vapourlang/vapour/walker"
 )
@@ -41,7 +44,7 @@ func (v *vapour) transpile(conf cli.CLI) bool {
 	}
 
 	// walk tree
-	w := walker.New()
+	w := walker.New(v.backend)
 	w.Walk(prog)
 
 	if w.HasDiagnostic() {
@@ -59,32 +62,26 @@ func (v *vapour) transpile(conf cli.CLI) bool {
 
 	// transpile
 	trans := transpiler.New()
+	trans.SetRoxygen(*conf.Roxygen)
+	trans.SetChecked(*conf.Checked)
 	trans.Transpile(prog)
 	code := trans.GetCode()
 
 	transpileSuccessful()
 
 	if *conf.Run {
-		run(code)
+		run(code, trans.GetSourceMap(*conf.Outfile))
 		return false
 	}
 
-	code = addHeader(code)
-
-	// write
-	path := *conf.Outdir + "/" + *conf.Outfile
-	f, err := os.Create(path)
-
-	if err != nil {
-		log.Fatalf("Failed to create output file: %v", err.Error())
+	if *conf.Layout == layoutSingle {
+		v.writeSingle(conf, trans)
+	} else {
+		v.writeFiles(conf, trans, prog)
 	}
 
-	defer f.Close()
-
-	_, err = f.WriteString(code)
-
-	if err != nil {
-		log.Fatalf("Failed to write output file: %v", err.Error())
+	if *conf.Namespace {
+		writeNamespace(*conf.Outdir, trans.GetNamespace())
 	}
 
 	// we only generate types if it's an R package
@@ -93,8 +90,14 @@ func (v *vapour) transpile(conf cli.CLI) bool {
 	}
 
 	// write types
-	lines := w.Env().GenerateTypes().String()
-	f, err = os.Create(*conf.Types)
+	types := w.Env().GenerateTypes()
+
+	for _, warning := range types.Warnings() {
+		fmt.Printf("%v: %v\n", *conf.Types, warning)
+	}
+
+	lines := types.String()
+	f, err := os.Create(*conf.Types)
 
 	if err != nil {
 		log.Fatalf("Failed to create type file: %v", err.Error())
@@ -111,6 +114,41 @@ func (v *vapour) transpile(conf cli.CLI) bool {
 	return true
 }
 
+// R packages keep their code in R/, its parent
+// holds DESCRIPTION and NAMESPACE
+func packageRoot(outdir string) (string, bool) {
+	dir := filepath.Clean(outdir)
+
+	if filepath.Base(dir) != "R" {
+		return "", false
+	}
+
+	return filepath.Dir(dir), true
+}
+
+func writeNamespace(outdir, namespace string) {
+	root, ok := packageRoot(outdir)
+
+	if !ok {
+		fmt.Println("-namespace only applies with -outdir R, NAMESPACE not written")
+		return
+	}
+
+	f, err := os.Create(filepath.Join(root, "NAMESPACE"))
+
+	if err != nil {
+		log.Fatalf("Failed to create NAMESPACE file: %v", err.Error())
+	}
+
+	defer f.Close()
+
+	_, err = f.WriteString(namespace)
+
+	if err != nil {
+		log.Fatalf("Failed to write to NAMESPACE file: %v", err.Error())
+	}
+}
+
 func (v *vapour) transpileFile(conf cli.CLI) bool {
 	content, err := os.ReadFile(*conf.Infile)
 
@@ -139,7 +177,7 @@ func (v *vapour) transpileFile(conf cli.CLI) bool {
 	}
 
 	// walk tree
-	w := walker.New()
+	w := walker.New(v.backend)
 	w.Walk(prog)
 	if w.HasDiagnostic() {
 		w.Errors().Print()
@@ -156,20 +194,23 @@ func (v *vapour) transpileFile(conf cli.CLI) bool {
 
 	// transpile
 	trans := transpiler.New()
+	trans.SetRoxygen(*conf.Roxygen)
+	trans.SetChecked(*conf.Checked)
 	trans.Transpile(prog)
 	code := trans.GetCode()
 
 	transpileSuccessful()
 
 	if *conf.Run {
-		run(code)
+		run(code, trans.GetSourceMap(*conf.Outfile))
 		return false
 	}
 
 	code = addHeader(code)
 
 	// write
-	f, err := os.Create(*conf.Outfile)
+	path := *conf.Outfile
+	f, err := os.Create(path)
 
 	if err != nil {
 		log.Fatal("Failed to create output file")
@@ -183,9 +224,24 @@ func (v *vapour) transpileFile(conf cli.CLI) bool {
 		log.Fatal("Failed to write to output file")
 	}
 
+	if *conf.SourceMap {
+		writeSourceMap(trans.GetSourceMap(path), path)
+	}
+
 	return true
 }
 
+// the map is written next to the R file, e.g.: vapour.R.map
+func writeSourceMap(smap *sourcemap.SourceMap, path string) {
+	smap.Shift(strings.Count(addHeader(""), "\n"))
+
+	err := smap.Write(path + ".map")
+
+	if err != nil {
+		log.Fatalf("Failed to write source map: %v", err.Error())
+	}
+}
+
 func transpileSuccessful() {
 	fmt.Println(cli.Green + "✓" + cli.Reset + " files successfully transpiled!")
 }
@@ -0,0 +1,202 @@
+package transpiler
+
+import (
+	"strings"
+
+	"github.com/vapourlang/vapour/ast"
+	"github.com/vapourlang/vapour/environment"
+)
+
+// name of the variable holding checked return values
+const checkedReturn = ".vp_return"
+
+// SetChecked emits runtime type checks in all functions
+// rather than only those decorated with @checked.
+func (t *Transpiler) SetChecked(checked bool) {
+	t.opts.checked = checked
+}
+
+func (t *Transpiler) enterChecked(checked bool) {
+	t.checked = append(t.checked, checked)
+}
+
+func (t *Transpiler) exitChecked() {
+	t.checked = t.checked[:len(t.checked)-1]
+}
+
+func (t *Transpiler) isChecked() bool {
+	if len(t.checked) == 0 {
+		return t.opts.checked
+	}
+
+	return t.checked[len(t.checked)-1]
+}
+
+func (t *Transpiler) transpileParameterChecks(node *ast.FunctionLiteral) {
+	if node.Body == nil {
+		return
+	}
+
+	if node.MethodVariable != "" && node.Method != nil {
+		check := t.checkTypes(node.MethodVariable, ast.Types{node.Method})
+		if check != "" {
+			t.addNewLine()
+			t.addCode("stopifnot(" + check + ")")
+		}
+	}
+
+	for _, p := range node.Parameters {
+		if p.Name == "..." {
+			continue
+		}
+
+		check := t.checkTypes(p.Name, p.Type)
+
+		if check == "" {
+			continue
+		}
+
+		t.addNewLine()
+
+		// parameters without default can be missing
+		if p.Default == nil {
+			t.addCode("if(!missing(" + p.Name + ")) ")
+		}
+
+		t.addCode("stopifnot(" + check + ")")
+	}
+}
+
+func (t *Transpiler) transpileCheckedReturn(node *ast.ReturnStatement) bool {
+	if !t.isChecked() {
+		return false
+	}
+
+	check := t.checkTypes(checkedReturn, t.env.ReturnType())
+
+	if check == "" {
+		return false
+	}
+
+	t.addCode(checkedReturn + " = ")
+	t.Transpile(node.ReturnValue)
+	t.addNewLine()
+	t.addCode("stopifnot(" + check + ")")
+	t.addNewLine()
+	t.addCode("return(" + checkedReturn + ")")
+
+	return true
+}
+
+func (t *Transpiler) transpileCheckedLet(node *ast.LetStatement) {
+	if !t.isChecked() || !t.isUntyped(node.Value) {
+		return
+	}
+
+	check := t.checkTypes(node.Name, node.Type)
+
+	if check == "" {
+		return
+	}
+
+	t.addNewLine()
+	t.addCode("stopifnot(" + check + ")")
+}
+
+// values we cannot type statically:
+// calls to R functions and variables typed any
+func (t *Transpiler) isUntyped(node ast.Expression) bool {
+	switch n := node.(type) {
+	case *ast.CallExpression:
+		_, isFunction := t.env.GetFunction(n.Name, true)
+		_, isType := t.env.GetType("", n.Name)
+		return !isFunction && !isType
+	case *ast.InfixExpression:
+		return n.Operator == "::" || n.Operator == ":::"
+	case *ast.Identifier:
+		v, exists := t.env.GetVariable(n.Value, true)
+		return exists && acceptAny(v.Value)
+	}
+
+	return false
+}
+
+func acceptAny(types ast.Types) bool {
+	for _, t := range types {
+		if t.Name == "any" {
+			return true
+		}
+	}
+	return false
+}
+
+// R condition checking that name is one of types,
+// empty if it cannot (or need not) be checked
+func (t *Transpiler) checkTypes(name string, types ast.Types) string {
+	if len(types) == 0 || acceptAny(types) {
+		return ""
+	}
+
+	var checks []string
+	for _, typ := range types {
+		check := t.checkType(name, typ)
+
+		if check == "" {
+			return ""
+		}
+
+		checks = append(checks, check)
+	}
+
+	return strings.Join(checks, " || ")
+}
+
+func (t *Transpiler) checkType(name string, typ *ast.Type) string {
+	if typ.List {
+		return "is.list(" + name + ")"
+	}
+
+	switch typ.Name {
+	// numbers are doubles in R unless suffixed with L
+	case "int", "num":
+		return "is.numeric(" + name + ")"
+	case "char":
+		return "is.character(" + name + ")"
+	case "bool":
+		return "is.logical(" + name + ")"
+	case "null":
+		return "is.null(" + name + ")"
+	case "nan":
+		return "all(is.nan(" + name + "))"
+	case "na", "na_char", "na_int", "na_real", "na_complex":
+		return "all(is.na(" + name + "))"
+	case "list":
+		return "is.list(" + name + ")"
+	case "dataframe":
+		return "is.data.frame(" + name + ")"
+	case "matrix":
+		return "is.matrix(" + name + ")"
+	case "factor":
+		return "is.factor(" + name + ")"
+	case "default":
+		return ""
+	}
+
+	if environment.IsNativeObject(typ.Name) {
+		return ""
+	}
+
+	custom, exists := t.env.GetType(typ.Package, typ.Name)
+
+	if exists && (custom.Object == "vector" || custom.Object == "impliedList") {
+		return t.checkTypes(name, custom.Type)
+	}
+
+	cl, exists := t.env.GetClass(typ.Name)
+
+	if exists {
+		return "inherits(" + name + ", c(\"" + strings.Join(cl.Value.Classes, "\", \"") + "\"))"
+	}
+
+	return "inherits(" + name + ", \"" + typ.Name + "\")"
+}
@@ -0,0 +1,70 @@
+package transpiler
+
+import "strings"
+
+// a range of code fragments transpiled from a vapour file
+type source struct {
+	file  string
+	start int
+	end   int
+}
+
+// code since the last call belongs to the previous file,
+// documentation comments stay with the file they are in
+func (t *Transpiler) setSource(file string) {
+	n := len(t.sources)
+
+	if n > 0 && t.sources[n-1].file == file {
+		return
+	}
+
+	t.flushDoc()
+	t.sources = append(t.sources, source{file: file, start: len(t.code)})
+}
+
+// ranges end where the next begins
+func (t *Transpiler) fileSources(file string) []source {
+	var sources []source
+	for i, s := range t.sources {
+		if s.file != file {
+			continue
+		}
+
+		s.end = len(t.code)
+		if i < len(t.sources)-1 {
+			s.end = t.sources[i+1].start
+		}
+
+		sources = append(sources, s)
+	}
+
+	return sources
+}
+
+// GetFiles returns the vapour files code was transpiled
+// from in the order they were first encountered.
+func (t *Transpiler) GetFiles() []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, s := range t.sources {
+		if seen[s.file] {
+			continue
+		}
+
+		seen[s.file] = true
+		files = append(files, s.file)
+	}
+
+	return files
+}
+
+// GetFileCode returns the code transpiled from a single
+// vapour file, GetCode returns that of all files.
+func (t *Transpiler) GetFileCode(file string) string {
+	var code []string
+	for _, s := range t.fileSources(file) {
+		code = append(code, t.code[s.start:s.end]...)
+	}
+
+	return strings.Join(code, "")
+}
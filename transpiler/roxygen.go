@@ -0,0 +1,206 @@
+package transpiler
+
+import (
+	"strings"
+
+	"github.com/vapourlang/vapour/ast"
+)
+
+type roxygenDoc struct {
+	title  bool
+	lines  []string
+	params map[string]string
+	ret    string
+}
+
+// nodes that documentation comments may precede
+func documents(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.CommentStatement, *ast.NewLine, *ast.ExpressionStatement:
+		return true
+	case *ast.DecoratorGeneric, *ast.DecoratorDefault, *ast.DecoratorExport:
+		return true
+	case *ast.FunctionLiteral:
+		return true
+	}
+
+	return false
+}
+
+func (t *Transpiler) flushDoc() {
+	doc := t.doc
+	t.doc = nil
+
+	for _, line := range doc {
+		t.addCode(line)
+		t.addNewLine()
+	}
+}
+
+func (t *Transpiler) transpileDoc(node *ast.FunctionLiteral, exported bool) {
+	if !t.opts.roxygen {
+		if exported {
+			t.transpileExport(node)
+		}
+
+		return
+	}
+
+	doc := parseRoxygen(t.doc)
+	t.doc = nil
+
+	if !doc.title {
+		t.addCode("#' " + rFunctionName(node) + "\n")
+	}
+
+	for _, line := range doc.lines {
+		t.addCode(line + "\n")
+	}
+
+	if node.MethodVariable != "" {
+		t.addCode(roxygenTag("@param", node.MethodVariable, node.Method.Name, doc.params[node.MethodVariable]))
+	}
+
+	for _, p := range node.Parameters {
+		t.addCode(roxygenTag("@param", p.Name, typesString(p.Type), doc.params[p.Name]))
+	}
+
+	if len(node.ReturnType) > 0 {
+		t.addCode(roxygenTag("@return", "", typesString(node.ReturnType), doc.ret))
+	}
+
+	if isMethod(node) {
+		t.addCode("#' @method " + node.Name + " " + node.Method.Name + "\n")
+	}
+
+	if exported {
+		t.addCode("#' @export\n")
+		t.addNamespace(node)
+	}
+}
+
+// roxygen tags for @export, S3 methods are registered
+// with S3method() rather than export()
+func (t *Transpiler) transpileExport(node *ast.FunctionLiteral) {
+	if isMethod(node) {
+		t.addCode("#' @method " + node.Name + " " + node.Method.Name + "\n")
+	}
+
+	t.addCode("#' @export\n")
+	t.addNamespace(node)
+}
+
+func (t *Transpiler) addNamespace(node *ast.FunctionLiteral) {
+	if isMethod(node) {
+		t.namespace = append(t.namespace, "S3method("+node.Name+", "+node.Method.Name+")")
+		return
+	}
+
+	t.namespace = append(t.namespace, "export("+node.Name+")")
+}
+
+// we merge the tags the user wrote with those
+// we derive from the signature, e.g.:
+// #' @param x the value
+// becomes
+// #' @param x int the value
+func parseRoxygen(doc []string) roxygenDoc {
+	rox := roxygenDoc{
+		params: make(map[string]string),
+	}
+
+	// tag the current line continues
+	current := ""
+	param := ""
+	for _, line := range doc {
+		text := strings.TrimSpace(strings.TrimPrefix(line, "#'"))
+
+		if strings.HasPrefix(text, "@") {
+			current = ""
+		}
+
+		if strings.HasPrefix(text, "@param ") {
+			fields := strings.Fields(text)
+			current = "@param"
+			param = ""
+			if len(fields) > 1 {
+				param = fields[1]
+				rox.params[param] = strings.Join(fields[2:], " ")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(text, "@return") {
+			current = "@return"
+			rox.ret = strings.TrimSpace(strings.TrimPrefix(text, "@return"))
+			continue
+		}
+
+		if current == "@param" && text != "" {
+			rox.params[param] = strings.TrimSpace(rox.params[param] + " " + text)
+			continue
+		}
+
+		if current == "@return" && text != "" {
+			rox.ret = strings.TrimSpace(rox.ret + " " + text)
+			continue
+		}
+
+		if len(rox.lines) == 0 && text != "" && !strings.HasPrefix(text, "@") {
+			rox.title = true
+		}
+
+		rox.lines = append(rox.lines, line)
+	}
+
+	return rox
+}
+
+func roxygenTag(tag, name, types, description string) string {
+	line := "#' " + tag
+
+	if name != "" {
+		line += " " + name
+	}
+
+	if types != "" {
+		line += " " + types
+	}
+
+	if description != "" {
+		line += " " + description
+	}
+
+	return line + "\n"
+}
+
+func isMethod(node *ast.FunctionLiteral) bool {
+	return node.Method != nil && node.Method.Name != "any"
+}
+
+func rFunctionName(node *ast.FunctionLiteral) string {
+	if isMethod(node) {
+		return node.Name + "." + node.Method.Name
+	}
+
+	return node.Name
+}
+
+func typesString(types ast.Types) string {
+	var strs []string
+	for _, t := range types {
+		name := t.Name
+
+		if t.Package != "" {
+			name = t.Package + "::" + name
+		}
+
+		if t.List {
+			name = "[]" + name
+		}
+
+		strs = append(strs, name)
+	}
+
+	return strings.Join(strs, " | ")
+}
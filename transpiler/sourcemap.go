@@ -0,0 +1,82 @@
+package transpiler
+
+import (
+	"strings"
+
+	"github.com/vapourlang/vapour/sourcemap"
+	"github.com/vapourlang/vapour/token"
+)
+
+// a source position at the start of a code fragment
+type mark struct {
+	index int
+	token token.Item
+}
+
+func (t *Transpiler) mark(tok token.Item) {
+	if tok.File == "" || tok.Class == token.ItemNewLine {
+		return
+	}
+
+	t.marks = append(t.marks, mark{index: len(t.code), token: tok})
+}
+
+// GetSourceMap maps the lines of the generated code
+// back to the vapour files they were transpiled from.
+func (t *Transpiler) GetSourceMap(file string) *sourcemap.SourceMap {
+	return t.sourceMap(file, "", []source{{start: 0, end: len(t.code)}})
+}
+
+// GetFileSourceMap maps the lines of the code transpiled
+// from a single vapour file, see GetFileCode.
+func (t *Transpiler) GetFileSourceMap(source, file string) *sourcemap.SourceMap {
+	return t.sourceMap(file, source, t.fileSources(source))
+}
+
+func (t *Transpiler) sourceMap(file, source string, sources []source) *sourcemap.SourceMap {
+	smap := sourcemap.New(file)
+
+	line, column := 1, 1
+	positions := make(map[int][2]int)
+	for _, s := range sources {
+		for i := s.start; i < s.end; i++ {
+			positions[i] = [2]int{line, column}
+
+			c := t.code[i]
+			newlines := strings.Count(c, "\n")
+			if newlines > 0 {
+				line += newlines
+				column = len(c) - strings.LastIndex(c, "\n")
+				continue
+			}
+
+			column += len(c)
+		}
+
+		if _, ok := positions[s.end]; !ok {
+			positions[s.end] = [2]int{line, column}
+		}
+	}
+
+	for _, m := range t.marks {
+		if source != "" && m.token.File != source {
+			continue
+		}
+
+		position, ok := positions[m.index]
+
+		if !ok {
+			continue
+		}
+
+		smap.Add(sourcemap.Mapping{
+			Line:         position[0],
+			Column:       position[1],
+			Source:       m.token.File,
+			SourceLine:   m.token.Line + 1,
+			SourceColumn: m.token.Char - len(m.token.Value) + 1,
+		})
+	}
+
+	return smap
+}
@@ -1,6 +1,7 @@
 package transpiler
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/vapourlang/vapour/ast"
@@ -8,14 +9,24 @@ import (
 )
 
 type Transpiler struct {
-	code []string
-	env  *environment.Environment
-	opts options
+	code      []string
+	doc       []string
+	namespace []string
+	checked   []bool
+	depth     int
+	marks     []mark
+	sources   []source
+	env       *environment.Environment
+	opts      options
 }
 
 type options struct {
 	inGeneric bool
 	inDefault bool
+	inExport  bool
+	inChecked bool
+	checked   bool
+	roxygen   bool
 }
 
 func (t *Transpiler) Env() *environment.Environment {
@@ -30,7 +41,19 @@ func New() *Transpiler {
 	}
 }
 
+// SetRoxygen enables the generation of roxygen2
+// documentation from function signatures.
+func (t *Transpiler) SetRoxygen(roxygen bool) {
+	t.opts.roxygen = roxygen
+}
+
 func (t *Transpiler) Transpile(node ast.Node) ast.Node {
+	// documentation comments are held until we know
+	// whether they document a function
+	if len(t.doc) > 0 && !documents(node) {
+		t.flushDoc()
+	}
+
 	switch node := node.(type) {
 
 	// Statements
@@ -53,10 +76,14 @@ func (t *Transpiler) Transpile(node ast.Node) ast.Node {
 		if node.Value != nil {
 			t.transpileLetStatement(node)
 			t.Transpile(node.Value)
+			t.transpileCheckedLet(node)
 			t.addNewLine()
 		}
 
 	case *ast.NewLine:
+		if len(t.doc) > 0 {
+			break
+		}
 		t.addNewLine()
 
 	case *ast.Comma:
@@ -82,6 +109,9 @@ func (t *Transpiler) Transpile(node ast.Node) ast.Node {
 
 	case *ast.ReturnStatement:
 		t.addNewLine()
+		if t.transpileCheckedReturn(node) {
+			break
+		}
 		t.addCode("return(")
 		t.Transpile(node.ReturnValue)
 		t.addCode(")")
@@ -109,10 +139,17 @@ func (t *Transpiler) Transpile(node ast.Node) ast.Node {
 		t.addCode(node.Value)
 
 	case *ast.CommentStatement:
+		// only documentation of top-level functions is rewritten
+		if t.opts.roxygen && t.depth == 0 && strings.HasPrefix(node.TokenLiteral(), "#'") {
+			t.doc = append(t.doc, node.TokenLiteral())
+			break
+		}
+		t.flushDoc()
 		t.addCode(node.TokenLiteral())
 
 	case *ast.BlockStatement:
 		for _, s := range node.Statements {
+			t.mark(s.Item())
 			t.Transpile(s)
 		}
 
@@ -273,16 +310,41 @@ func (t *Transpiler) Transpile(node ast.Node) ast.Node {
 		}
 
 	case *ast.FunctionLiteral:
-		t.env = environment.Enclose(t.env, node.ReturnType)
-
-		if node.Name != "" {
-			t.addCode(node.Name)
+		if node.Name != "" && node.Method == nil {
+			t.env.SetFunction(node.Name, environment.Function{Token: node.Token, Value: node})
 		}
 
+		t.env = environment.Enclose(t.env, node.ReturnType)
+
 		if t.opts.inDefault {
 			node.Method = &ast.Type{Name: "default"}
 		}
 
+		exported := t.opts.inExport
+		t.opts.inExport = false
+
+		t.enterChecked(t.opts.checked || t.opts.inChecked)
+		t.opts.inChecked = false
+
+		// roxygen2 attaches blocks to top-level objects
+		documented := node.Name != "" && t.depth == 0
+
+		if documented {
+			t.transpileDoc(node, exported)
+		}
+
+		if !documented {
+			t.flushDoc()
+
+			if exported {
+				t.transpileExport(node)
+			}
+		}
+
+		if node.Name != "" {
+			t.addCode(node.Name)
+		}
+
 		if node.Method != nil && node.Method.Name != "any" {
 			t.addCode("." + node.Method.Name)
 		}
@@ -325,6 +387,13 @@ func (t *Transpiler) Transpile(node ast.Node) ast.Node {
 		}
 
 		t.addCode(") {")
+
+		if t.isChecked() {
+			t.transpileParameterChecks(node)
+		}
+
+		t.depth++
+
 		if node.Body != nil {
 			t.Transpile(node.Body)
 		}
@@ -333,6 +402,9 @@ func (t *Transpiler) Transpile(node ast.Node) ast.Node {
 			t.addCode("UseMethod(\"" + node.Name + "\")")
 		}
 
+		t.depth--
+
+		t.exitChecked()
 		t.env = environment.Open(t.env)
 		t.addCode("}")
 
@@ -377,6 +449,23 @@ func (t *Transpiler) Transpile(node ast.Node) ast.Node {
 		t.opts.inDefault = false
 		return n
 
+	case *ast.DecoratorExport:
+		if node.Type != nil {
+			t.Transpile(node.Type)
+			break
+		}
+
+		t.opts.inExport = true
+		n := t.Transpile(node.Func)
+		t.opts.inExport = false
+		return n
+
+	case *ast.DecoratorChecked:
+		t.opts.inChecked = true
+		n := t.Transpile(node.Func)
+		t.opts.inChecked = false
+		return n
+
 	case *ast.CallExpression:
 		t.transpileCallExpression(node)
 		//t.addNewLine() // avoid newline after return(x)
@@ -389,6 +478,8 @@ func (t *Transpiler) transpileProgram(program *ast.Program) ast.Node {
 	var node ast.Node
 
 	for _, statement := range program.Statements {
+		t.setSource(statement.Item().File)
+		t.mark(statement.Item())
 		node := t.Transpile(statement)
 
 		switch n := node.(type) {
@@ -402,6 +493,8 @@ func (t *Transpiler) transpileProgram(program *ast.Program) ast.Node {
 		}
 	}
 
+	t.flushDoc()
+
 	return node
 }
 
@@ -590,6 +683,24 @@ func (t *Transpiler) transpileCallExpressionStruct(node *ast.CallExpression, typ
 	t.addCode(")")
 }
 
+// GetNamespace returns the content of the NAMESPACE file
+// derived from the @export decorators.
+func (t *Transpiler) GetNamespace() string {
+	entries := []string{}
+	seen := make(map[string]bool)
+	for _, e := range t.namespace {
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+		entries = append(entries, e)
+	}
+
+	sort.Strings(entries)
+
+	return "# Generated by vapour: do not edit by hand\n\n" + strings.Join(entries, "\n") + "\n"
+}
+
 func (t *Transpiler) GetCode() string {
 	return strings.Join(t.code, "")
 }
@@ -599,6 +710,15 @@ func (t *Transpiler) addCode(code string) {
 }
 
 func (t *Transpiler) popCode() {
+	// marks on the next fragment move back with it
+	for i := len(t.marks) - 1; i >= 0 && t.marks[i].index == len(t.code); i-- {
+		t.marks[i].index--
+	}
+
+	for i := len(t.sources) - 1; i >= 0 && t.sources[i].start == len(t.code); i-- {
+		t.sources[i].start--
+	}
+
 	t.code = t.code[:len(t.code)-1]
 }
 
@@ -1,6 +1,7 @@
 package transpiler
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/vapourlang/vapour/lexer"
@@ -481,6 +482,8 @@ y[[1]] = 1
 
 let zz: char = ("hello|world", "hello|again")
 let z: char = strsplit(zz[2], "\\|")[[1]]
+
+let age: int = df[, "age"]
 `
 
 	l := lexer.NewTest(code)
@@ -500,6 +503,7 @@ y[[1]]=1
 zz = c("hello|world", "hello|again")
 z = strsplit(zz[2
 , ], "\\|")[[1]]
+age = df[,"age"]
 `
 
 	trans.testOutput(t, expected)
@@ -967,3 +971,254 @@ print(x)
 
 	trans.testOutput(t, expected)
 }
+
+func TestExport(t *testing.T) {
+	code := `type person: object {
+  name: char
+}
+
+@export
+func create(name: char): person {
+  return person(name = name)
+}
+
+@export
+func (p: person) greet(): null {
+  print(p$name)
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	trans := New()
+	trans.Transpile(prog)
+
+	expected := `#' @export
+create = function(name) {
+return(structure(list(name=name
+), class=c("person", "list")))
+}
+#' @method greet person
+#' @export
+greet.person = function(p) {
+print(p$name
+)}
+`
+
+	trans.testOutput(t, expected)
+
+	namespace := `# Generated by vapour: do not edit by hand
+
+S3method(greet, person)
+export(create)
+`
+
+	if trans.GetNamespace() != namespace {
+		t.Fatalf("expected:\n`%v`\ngot:\n`%v`", namespace, trans.GetNamespace())
+	}
+}
+
+func TestRoxygen(t *testing.T) {
+	code := `#' Add numbers
+#' @param x first number
+#' @return the sum
+func add(x: int, y: int | na = 1): int {
+  return x + y
+}
+
+@export
+func (p: person) greet(...: any): null {
+  print(p)
+}
+
+func outer(x: int): int {
+  #' not documented, roxygen2 only sees top-level objects
+  func inner(y: int): int {
+    return y
+  }
+  return inner(x)
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	trans := New()
+	trans.SetRoxygen(true)
+	trans.Transpile(prog)
+
+	expected := `#' Add numbers
+#' @param x int first number
+#' @param y int | na
+#' @return int the sum
+add = function(x, y = 1) {
+return(x+y
+)
+}
+#' greet.person
+#' @param p person
+#' @param ... any
+#' @return null
+#' @method greet person
+#' @export
+greet.person = function(p, ...) {
+print(p)}
+#' outer
+#' @param x int
+#' @return int
+outer = function(x) {
+#' not documented, roxygen2 only sees top-level objects
+inner = function(y) {
+return(y)
+}
+return(inner(x))
+}
+`
+
+	trans.testOutput(t, expected)
+}
+
+func TestRoxygenOff(t *testing.T) {
+	code := `#' Add numbers
+func add(x: int): int {
+  return x
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	trans := New()
+	trans.Transpile(prog)
+
+	expected := `#' Add numbers
+add = function(x) {
+return(x)
+}
+`
+
+	trans.testOutput(t, expected)
+}
+
+func TestChecked(t *testing.T) {
+	code := `type person: object {
+  name: char
+}
+
+@checked
+func greet(p: person, greeting: char = "hello"): char {
+  let n: int = nchar(greeting)
+  return paste(greeting, p$name)
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	trans := New()
+	trans.Transpile(prog)
+
+	expected := `greet = function(p, greeting = "hello") {
+if(!missing(p)) stopifnot(inherits(p, "person"))
+stopifnot(is.character(greeting))
+n = nchar(greeting)
+stopifnot(is.numeric(n))
+.vp_return = paste(greeting, p$name
+)
+stopifnot(is.character(.vp_return))
+return(.vp_return)
+}
+`
+
+	trans.testOutput(t, expected)
+}
+
+func TestSourceMap(t *testing.T) {
+	code := `let x: int = 1
+
+func foo(y: int): int {
+  stop("error")
+  return y
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	trans := New()
+	trans.Transpile(prog)
+
+	smap := trans.GetSourceMap("vapour.R")
+
+	m, ok := smap.Lookup(3)
+
+	if !ok {
+		t.Fatalf("expected mapping for line 3")
+	}
+
+	if m.Source != "test.vp" || m.SourceLine != 4 || m.SourceColumn != 3 {
+		t.Fatalf("expected test.vp:4:3, got %v:%v:%v", m.Source, m.SourceLine, m.SourceColumn)
+	}
+
+	output := smap.Rewrite("Error in foo(1) (from vapour.R#3) : error")
+	expected := "Error in foo(1) (from test.vp:4:3) : error"
+
+	if output != expected {
+		t.Fatalf("expected `%v`, got `%v`", expected, output)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	l := lexer.New(lexer.Files{
+		{Path: "a.vp", Content: []byte("let x: int = 1\n")},
+		{Path: "b.vp", Content: []byte("let y: int = x\n")},
+	})
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	trans := New()
+	trans.SetRoxygen(false)
+	trans.Transpile(prog)
+
+	files := trans.GetFiles()
+
+	if len(files) != 2 || files[0] != "a.vp" || files[1] != "b.vp" {
+		t.Fatalf("expected [a.vp b.vp], got %v", files)
+	}
+
+	code := trans.GetFileCode("b.vp")
+
+	if strings.Contains(code, "x = 1") || !strings.Contains(code, "y = x") {
+		t.Fatalf("unexpected code for b.vp: `%v`", code)
+	}
+
+	m, ok := trans.GetFileSourceMap("b.vp", "b.R").Lookup(1)
+
+	if !ok || m.Source != "b.vp" || m.SourceLine != 1 {
+		t.Fatalf("expected b.R:1 to map to b.vp:1, got %v", m)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/vapourlang/vapour/config"
 	"github.com/vapourlang/vapour/lexer"
+	"github.com/vapourlang/vapour/r"
 )
 
 type vapour struct {
@@ -11,6 +12,7 @@ type vapour struct {
 	root    *string
 	files   lexer.Files
 	config  *config.Config
+	backend r.Backend
 }
 
 func New() *vapour {
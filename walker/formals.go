@@ -0,0 +1,126 @@
+package walker
+
+import (
+	"strings"
+
+	"github.com/vapourlang/vapour/ast"
+	"github.com/vapourlang/vapour/r"
+)
+
+// we cannot type the arguments of R functions but we can check
+// they match the formals, as R does: exact names, then partial
+// names, then positions, arguments after ... must be named.
+func (w *Walker) checkFormals(node *ast.CallExpression, pkg, operator string) {
+	formals, err := w.backend.Formals(pkg, operator, node.Function)
+
+	// empty formals are builtins args() cannot describe
+	if err != nil || len(formals) == 0 {
+		return
+	}
+
+	name := pkg + operator + node.Function
+	dots := hasDots(formals)
+	matched := make(map[string]bool)
+
+	positional := 0
+	for _, a := range node.Arguments {
+		if a.Name == "" {
+			positional++
+			continue
+		}
+
+		formal, ok := matchFormal(formals, a.Name)
+
+		if ok {
+			matched[formal] = true
+			continue
+		}
+
+		if dots {
+			continue
+		}
+
+		w.addFatalf(
+			a.Token,
+			"`%v` has no argument `%v`",
+			name,
+			a.Name,
+		)
+	}
+
+	// positional arguments fill the formals before ...
+	for _, f := range formals {
+		if positional == 0 || f.Name == "..." {
+			break
+		}
+
+		if matched[f.Name] {
+			continue
+		}
+
+		matched[f.Name] = true
+		positional--
+	}
+
+	if positional > 0 && !dots {
+		w.addFatalf(
+			node.Token,
+			"too many arguments to `%v`, expects at most %v",
+			name,
+			len(formals),
+		)
+	}
+
+	// R evaluates arguments lazily so a missing argument
+	// only errors if the function uses it
+	for _, f := range formals {
+		if f.Name == "..." || f.Value != "" || matched[f.Name] {
+			continue
+		}
+
+		w.addWarnf(
+			node.Token,
+			"`%v` missing argument `%v`",
+			name,
+			f.Name,
+		)
+	}
+}
+
+func hasDots(formals []r.Formal) bool {
+	for _, f := range formals {
+		if f.Name == "..." {
+			return true
+		}
+	}
+	return false
+}
+
+// partial matching only applies to formals before ...
+// and must be unambiguous
+func matchFormal(formals []r.Formal, name string) (string, bool) {
+	for _, f := range formals {
+		if f.Name == name {
+			return f.Name, true
+		}
+	}
+
+	match := ""
+	for _, f := range formals {
+		if f.Name == "..." {
+			break
+		}
+
+		if !strings.HasPrefix(f.Name, name) {
+			continue
+		}
+
+		if match != "" {
+			return "", false
+		}
+
+		match = f.Name
+	}
+
+	return match, match != ""
+}
@@ -0,0 +1,111 @@
+package walker
+
+import (
+	"sort"
+
+	"github.com/vapourlang/vapour/ast"
+	"github.com/vapourlang/vapour/environment"
+)
+
+// methods must accept the arguments of their generic,
+// as R CMD check does for S3 generic/method consistency
+func (w *Walker) checkGenerics() {
+	var names []string
+	for name := range w.env.Methods() {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		methods := w.env.Methods()[name]
+		generic, ok := methods.Generic()
+
+		// methods of packages are checked by their authors
+		if !ok || generic.Package != "" {
+			continue
+		}
+
+		if len(methods) == 1 {
+			w.addWarnf(
+				generic.Value.NameToken,
+				"generic `%v` has no methods",
+				name,
+			)
+			continue
+		}
+
+		for _, m := range methods {
+			if m.Generic {
+				continue
+			}
+
+			w.checkMethod(generic.Value, m)
+		}
+	}
+}
+
+func (w *Walker) checkMethod(generic *ast.FunctionLiteral, m environment.Method) {
+	method := m.Value
+	params := method.Parameters
+
+	if method.MethodVariable != generic.MethodVariable {
+		w.addFatalf(
+			method.NameToken,
+			"method `%v` on `%v` names its receiver `%v`, its generic names it `%v`",
+			method.Name,
+			method.Method.Name,
+			method.MethodVariable,
+			generic.MethodVariable,
+		)
+	}
+
+	for i, g := range generic.Parameters {
+		if g.Name == "..." {
+			if !hasElipsis(params) {
+				w.addWarnf(
+					method.NameToken,
+					"method `%v` on `%v` should accept `...` as its generic does",
+					method.Name,
+					method.Method.Name,
+				)
+			}
+			continue
+		}
+
+		if i >= len(params) || params[i].Name != g.Name {
+			w.addFatalf(
+				method.NameToken,
+				"method `%v` on `%v` expects parameter #%v to be `%v` as its generic does",
+				method.Name,
+				method.Method.Name,
+				i+1,
+				g.Name,
+			)
+			return
+		}
+
+		if !w.typesValid(g.Type, params[i].Type) {
+			w.addFatalf(
+				params[i].Token,
+				"parameter `%v` of method `%v` on `%v` expects `%v` as its generic, got `%v`",
+				g.Name,
+				method.Name,
+				method.Method.Name,
+				g.Type,
+				params[i].Type,
+			)
+		}
+	}
+
+	if !w.typesValid(generic.ReturnType, method.ReturnType) {
+		w.addFatalf(
+			method.NameToken,
+			"method `%v` on `%v` returns `%v`, its generic returns `%v`",
+			method.Name,
+			method.Method.Name,
+			method.ReturnType,
+			generic.ReturnType,
+		)
+	}
+}
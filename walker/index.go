@@ -0,0 +1,133 @@
+package walker
+
+import (
+	"strconv"
+
+	"github.com/vapourlang/vapour/ast"
+	"github.com/vapourlang/vapour/environment"
+)
+
+// indexTypes is what x[i], x[[i]] or x[, j] returns given the types
+// of x, the operator of the latter is ",". The index is only used
+// when it is a literal.
+func (w *Walker) indexTypes(operator string, types ast.Types, index ast.Node) ast.Types {
+	// we don't know what we index
+	if len(types) == 0 || acceptAny(types) {
+		return ast.Types{}
+	}
+
+	var result ast.Types
+	for _, t := range types {
+		result = append(result, w.indexType(operator, t, index)...)
+	}
+
+	return result
+}
+
+func (w *Walker) indexType(operator string, t *ast.Type, index ast.Node) ast.Types {
+	element := operator == "[[" || (operator == "[" && scalarIndex(index))
+
+	// []int[1] is an int, []int[cond] is still a []int
+	if t.List {
+		if element {
+			return ast.Types{{Name: t.Name, Package: t.Package}}
+		}
+
+		return ast.Types{t}
+	}
+
+	custom, exists := w.env.GetType(t.Package, t.Name)
+
+	// native vectors, x[cond] is still a vector
+	if !exists || custom.Object == "" {
+		return ast.Types{t}
+	}
+
+	switch custom.Object {
+	case "vector", "impliedList":
+		if element {
+			return w.indexTypes(operator, custom.Type, index)
+		}
+	case "matrix":
+		return custom.Type
+	// a struct indexes its underlying vector, whose length is unknown
+	case "list", "struct":
+		if operator == "[[" {
+			return custom.Type
+		}
+	case "object":
+		if operator == "[[" {
+			return w.attributeIndex(custom, index)
+		}
+	case "dataframe":
+		if operator == "[[" || operator == "," {
+			return w.attributeIndex(custom, index)
+		}
+	}
+
+	return ast.Types{t}
+}
+
+// obj[["name"]], obj[[2]] or df[, "name"], attributes of objects
+// and columns of dataframes are fixed so we can check the index
+func (w *Walker) attributeIndex(t environment.Type, index ast.Node) ast.Types {
+	switch n := index.(type) {
+	case *ast.StringLiteral:
+		types, ok := w.getAttribute(n.Str, t.Attributes)
+
+		if !ok {
+			w.addFatalf(
+				n.Token,
+				"`%v` unknown %v on `%v`",
+				n.Str,
+				attributeKind(t),
+				t.Name,
+			)
+			w.suggest(n.Token, n.Str, attributeNames(t))
+			return ast.Types{}
+		}
+
+		return types
+	case *ast.IntegerLiteral:
+		i, err := strconv.Atoi(n.Value)
+
+		if err != nil {
+			return ast.Types{}
+		}
+
+		if i < 1 || i > len(t.Attributes) {
+			w.addWarnf(
+				n.Token,
+				"index %v out of range, `%v` has %v %vs",
+				i,
+				t.Name,
+				len(t.Attributes),
+				attributeKind(t),
+			)
+			return ast.Types{}
+		}
+
+		return t.Attributes[i-1].Type
+	}
+
+	// the index is only known at runtime
+	return ast.Types{}
+}
+
+// x[1] or x["name"] select a single element
+func scalarIndex(index ast.Node) bool {
+	switch index.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral:
+		return true
+	}
+
+	return false
+}
+
+func attributeKind(t environment.Type) string {
+	if t.Object == "dataframe" {
+		return "column"
+	}
+
+	return "attribute"
+}
@@ -0,0 +1,79 @@
+package walker
+
+import (
+	"github.com/vapourlang/vapour/ast"
+)
+
+type iterator struct {
+	fn      string
+	vectors []string
+}
+
+// functions of the base stubs that call fn on the
+// elements of the vectors, e.g.: lapply(X, FUN)
+var iterators = map[string]iterator{
+	"lapply": {fn: "FUN", vectors: []string{"X"}},
+	"sapply": {fn: "FUN", vectors: []string{"X"}},
+	"vapply": {fn: "FUN", vectors: []string{"X"}},
+	"Map":    {fn: "f", vectors: []string{"..."}},
+	"Filter": {fn: "f", vectors: []string{"x"}},
+}
+
+// typeLambda infers the types of the parameters an anonymous
+// function omits from the parameter it is passed to, walked
+// is the types of the other arguments by parameter.
+func (w *Walker) typeLambda(value ast.Expression, param *ast.Parameter, fn *ast.FunctionLiteral, walked map[string][]ast.Types) {
+	lambda, ok := value.(*ast.FunctionLiteral)
+
+	if !ok || lambda.Name != "" {
+		return
+	}
+
+	signature, ok := w.canBeFunction(param.Type)
+
+	if ok {
+		contextualize(lambda, signature.Value.Arguments, signature.Value.Return)
+		return
+	}
+
+	it, ok := iterators[fn.Name]
+
+	if !ok || it.fn != param.Name || !w.isStub(fn) {
+		return
+	}
+
+	var elements []ast.Types
+	for _, v := range it.vectors {
+		for _, t := range walked[v] {
+			elements = append(elements, w.indexTypes("[[", t, nil))
+		}
+	}
+
+	contextualize(lambda, elements, nil)
+}
+
+func (w *Walker) isStub(fn *ast.FunctionLiteral) bool {
+	f, ok := w.env.GetFunction(fn.Name, true)
+	return ok && f.Typed && f.Package == "base"
+}
+
+// the expected return type is checked in the body of the
+// lambda, against its return statements
+func contextualize(lambda *ast.FunctionLiteral, params []ast.Types, returnType ast.Types) {
+	for i, p := range lambda.Parameters {
+		if p.Type != nil || i >= len(params) {
+			continue
+		}
+
+		p.Type = params[i]
+
+		// not nil, the type could not be inferred
+		if p.Type == nil {
+			p.Type = ast.Types{}
+		}
+	}
+
+	if lambda.ReturnType == nil && returnType != nil {
+		lambda.ReturnType = returnType
+	}
+}
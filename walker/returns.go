@@ -0,0 +1,192 @@
+package walker
+
+import (
+	"github.com/vapourlang/vapour/ast"
+	"github.com/vapourlang/vapour/environment"
+	"github.com/vapourlang/vapour/token"
+)
+
+// returns reports whether every path through the statements
+// ends in a return or stop(), code that follows is flagged.
+func (w *Walker) returns(statements []ast.Statement) bool {
+	terminated := false
+	var end token.Item
+	for _, s := range statements {
+		if terminated {
+			if continues(s, end) {
+				continue
+			}
+
+			w.addWarnf(
+				s.Item(),
+				"unreachable code",
+			)
+			break
+		}
+
+		if w.statementReturns(s) {
+			terminated = true
+			end = s.Item()
+		}
+	}
+
+	return terminated
+}
+
+func (w *Walker) statementReturns(statement ast.Statement) bool {
+	switch s := statement.(type) {
+	case *ast.ReturnStatement:
+		return true
+	case *ast.ExpressionStatement:
+		return w.expressionReturns(s.Expression)
+	}
+
+	// defer runs on exit, it does not return for us
+	return false
+}
+
+func (w *Walker) expressionReturns(expression ast.Expression) bool {
+	switch e := expression.(type) {
+	case *ast.IfExpression:
+		// both branches are checked for unreachable code
+		consequence := w.returns(e.Consequence.Statements)
+
+		if e.Alternative == nil {
+			return false
+		}
+
+		return w.returns(e.Alternative.Statements) && consequence
+	case *ast.For:
+		// the vector may be empty
+		w.returns(e.Value.Statements)
+		return false
+	case *ast.While:
+		// the body may not run, there is no break
+		// so while(TRUE) only exits with a return
+		w.returns(e.Value.Statements)
+		return loopsForever(e)
+	case *ast.CallExpression:
+		return e.Function == "stop"
+	case *ast.InfixExpression:
+		// base::stop()
+		if e.Operator != "::" || e.Left == nil || e.Left.Item().Value != "base" {
+			return false
+		}
+
+		call, ok := e.Right.(*ast.CallExpression)
+
+		return ok && call.Function == "stop"
+	}
+
+	return false
+}
+
+func loopsForever(node *ast.While) bool {
+	s, ok := node.Statement.(*ast.ExpressionStatement)
+
+	if !ok {
+		return false
+	}
+
+	b, ok := s.Expression.(*ast.Boolean)
+
+	return ok && b.Value
+}
+
+// the parser emits the end of some expressions, e.g.: the
+// bracket of x[1], as statements on the same line
+func continues(statement ast.Statement, end token.Item) bool {
+	switch s := statement.(type) {
+	case *ast.NewLine, *ast.CommentStatement:
+		return true
+	case *ast.ExpressionStatement:
+		switch s.Expression.(type) {
+		case nil, *ast.Square, *ast.Comma:
+			return true
+		}
+	}
+
+	return statement.Item().Line == end.Line && statement.Item().File == end.File
+}
+
+// functions without a return type return what they
+// return, we check against nothing and infer it on exit
+func (w *Walker) enterFunction(node *ast.FunctionLiteral) {
+	returnType := node.ReturnType
+
+	// not nil, or the enclosing function's type applies
+	if returnType == nil {
+		returnType = ast.Types{}
+	}
+
+	w.env = environment.Enclose(w.env, returnType)
+	w.state.returned = append(w.state.returned, ast.Types{})
+}
+
+// the inferred type is set on the function literal, thus
+// on the environment.Function, callers and generated types
+func (w *Walker) exitFunction(node *ast.FunctionLiteral) {
+	last := len(w.state.returned) - 1
+	returned := w.state.returned[last]
+	w.state.returned = w.state.returned[:last]
+
+	if node.ReturnType == nil && node.Body != nil {
+		node.ReturnType = returned
+	}
+
+	w.env = environment.Open(w.env)
+}
+
+func (w *Walker) addReturned(types ast.Types) {
+	if len(w.state.returned) == 0 {
+		return
+	}
+
+	last := len(w.state.returned) - 1
+	w.state.returned[last] = unionTypes(w.state.returned[last], types)
+}
+
+// the value of the last expression is returned too
+func (w *Walker) walkFunctionBody(body *ast.BlockStatement) {
+	last := lastStatement(body.Statements)
+
+	for i, s := range body.Statements {
+		t, _ := w.Walk(s)
+
+		if i != last {
+			continue
+		}
+
+		if _, ok := s.(*ast.ExpressionStatement); ok {
+			w.addReturned(t)
+		}
+	}
+}
+
+func lastStatement(statements []ast.Statement) int {
+	for i := len(statements) - 1; i >= 0; i-- {
+		if !continues(statements[i], token.Item{Line: -1}) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func unionTypes(types, add ast.Types) ast.Types {
+	for _, a := range add {
+		found := false
+		for _, t := range types {
+			if typeIdentical(t, a) && t.Package == a.Package {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			types = append(types, a)
+		}
+	}
+
+	return types
+}
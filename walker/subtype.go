@@ -0,0 +1,57 @@
+package walker
+
+import (
+	"github.com/vapourlang/vapour/ast"
+)
+
+// the types a native type is directly accepted as, a plain
+// NA is only accepted where na is declared, e.g.: int | na
+var supertypes = map[string][]string{
+	"int":        {"num"},
+	"na_int":     {"int", "na"},
+	"na_real":    {"num", "na"},
+	"na_char":    {"char", "na"},
+	"na_complex": {"na"},
+}
+
+// subtype is the subtyping relation of the checker: whether a
+// value of type t is accepted where v is expected. null is only
+// accepted as null, lists are covariant: []int is a []num.
+func (w *Walker) subtype(t, v *ast.Type) bool {
+	if v.Name == "any" {
+		return true
+	}
+
+	if t.List != v.List {
+		return false
+	}
+
+	if t.Name == v.Name {
+		return true
+	}
+
+	for _, s := range supertypes[t.Name] {
+		if s == v.Name || w.subtype(&ast.Type{Name: s, List: t.List}, v) {
+			return true
+		}
+	}
+
+	return w.inherits(t, v)
+}
+
+// a type inherits from the classes after it in its @class vector
+func (w *Walker) inherits(t, v *ast.Type) bool {
+	classes := w.env.Classes(t)
+
+	for i, c := range classes {
+		if i == 0 && c == t.Name {
+			continue
+		}
+
+		if c == v.Name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,127 @@
+package walker
+
+import (
+	"sort"
+
+	"github.com/vapourlang/vapour/diagnostics"
+	"github.com/vapourlang/vapour/environment"
+	"github.com/vapourlang/vapour/token"
+)
+
+// suggest attaches the candidates closest to a misspelled
+// name to the last diagnostic, tok is where name is written
+func (w *Walker) suggest(tok token.Item, name string, candidates []string) {
+	if len(w.errors) == 0 {
+		return
+	}
+
+	last := &w.errors[len(w.errors)-1]
+	for _, c := range closestNames(name, candidates) {
+		last.Suggestions = append(
+			last.Suggestions,
+			diagnostics.Suggestion{Token: tok, Value: c},
+		)
+	}
+}
+
+// the candidates at the smallest distance to name,
+// provided it is small for the length of name
+func closestNames(name string, candidates []string) []string {
+	var closest []string
+	best := len(name)/3 + 1
+
+	sort.Strings(candidates)
+
+	for i, c := range candidates {
+		if i > 0 && candidates[i-1] == c {
+			continue
+		}
+
+		d := editDistance(name, c)
+
+		if d == 0 || d > best {
+			continue
+		}
+
+		if d < best {
+			closest = nil
+			best = d
+		}
+
+		closest = append(closest, c)
+	}
+
+	return closest
+}
+
+// optimal string alignment distance: insertions, deletions,
+// substitutions and transpositions of adjacent characters
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(a)][len(b)]
+}
+
+func attributeNames(t environment.Type) []string {
+	var names []string
+	for _, a := range t.Attributes {
+		names = append(names, a.Name)
+	}
+
+	return names
+}
+
+// functions of pkg known from its types or from R
+func (w *Walker) packageFunctions(pkg string) []string {
+	names := w.env.PackageFunctions(pkg)
+
+	exports, err := w.backend.Exports(pkg)
+
+	if err != nil {
+		return names
+	}
+
+	for _, fn := range exports.Functions {
+		names = append(names, fn.Name)
+	}
+
+	return names
+}
+
+// calls only keep the name of the function, its position
+// follows that of the package, e.g.: dplyr::filter
+func functionToken(pkg token.Item, operator, fn string) token.Item {
+	offset := len(operator) + len(fn)
+
+	return token.Item{
+		Class: token.ItemIdent,
+		Value: fn,
+		Line:  pkg.Line,
+		Pos:   pkg.Pos + offset,
+		Char:  pkg.Char + offset,
+		File:  pkg.File,
+	}
+}
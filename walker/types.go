@@ -87,11 +87,7 @@ func (w *Walker) typeValid(t *ast.Type, valid ast.Types) bool {
 	}
 
 	for _, v := range valid {
-		if typeIdentical(t, v) {
-			return true
-		}
-
-		if v.Name == "num" && t.Name == "int" && v.List == t.List {
+		if w.subtype(t, v) {
 			return true
 		}
 	}
@@ -120,6 +116,26 @@ func (w *Walker) validAccessType(types ast.Types) bool {
 	return true
 }
 
+// lists and vector types can also be indexed
+func (w *Walker) validIndexType(types ast.Types) bool {
+	for _, t := range types {
+		if t.List {
+			continue
+		}
+
+		obj, exists := w.env.GetType(t.Package, t.Name)
+
+		if exists && contains(obj.Object, []string{"vector", "impliedList", "list", "matrix"}) {
+			continue
+		}
+
+		if !w.validAccessType(ast.Types{t}) {
+			return false
+		}
+	}
+	return true
+}
+
 func (w *Walker) validMathTypes(types ast.Types) bool {
 	types, ok := w.getNativeTypes(types)
 
@@ -271,6 +287,7 @@ func (w *Walker) checkIdentifier(node *ast.Identifier) {
 			"`%v` not found",
 			node.Value,
 		)
+		w.suggest(node.Token, node.Value, w.env.Names())
 	}
 }
 
@@ -308,6 +325,7 @@ func (w *Walker) attributeMatch(arg ast.Argument, inc ast.Types, t environment.T
 			"attribute `%v` not found",
 			arg.Name,
 		)
+		w.suggest(arg.Token, arg.Name, attributeNames(t))
 		return false
 	}
 
@@ -340,6 +358,45 @@ func (w *Walker) warnUnusedTypes() {
 	}
 }
 
+// exported functions should not expose types
+// that users of the package cannot access
+func (w *Walker) warnUnexportedTypes() {
+	for _, fn := range w.state.exported {
+		types := ast.Types{}
+
+		if fn.Method != nil {
+			types = append(types, fn.Method)
+		}
+
+		for _, p := range fn.Parameters {
+			types = append(types, p.Type...)
+		}
+
+		types = append(types, fn.ReturnType...)
+
+		for _, t := range types {
+			if t.Package != "" || environment.IsNativeType(t.Name) || environment.IsNativeObject(t.Name) {
+				continue
+			}
+
+			custom, exists := w.env.GetType("", t.Name)
+
+			if !exists || custom.Exported {
+				continue
+			}
+
+			w.addWarnf(
+				fn.NameToken,
+				"exported `%v` uses unexported type `%v`",
+				fn.Name,
+				t.Name,
+			)
+		}
+	}
+
+	w.state.exported = nil
+}
+
 func (w *Walker) warnUnusedVariables() {
 	for k, v := range w.env.Variables() {
 		if v.Used {
@@ -449,16 +506,9 @@ func (w *Walker) comparisonValid(t *ast.Type, valid ast.Types) bool {
 		return true
 	}
 
+	// comparable if either is accepted as the other
 	for _, v := range valid {
-		if typeIdentical(t, v) {
-			return true
-		}
-
-		if v.Name == "num" && t.Name == "int" && v.List == t.List {
-			return true
-		}
-
-		if v.Name == "int" && t.Name == "num" && v.List == t.List {
+		if w.subtype(t, v) || w.subtype(v, t) {
 			return true
 		}
 	}
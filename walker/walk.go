@@ -8,21 +8,29 @@ import (
 )
 
 type Walker struct {
-	errors diagnostics.Diagnostics
-	env    *environment.Environment
-	state  state
+	errors  diagnostics.Diagnostics
+	env     *environment.Environment
+	backend r.Backend
+	state   state
 }
 
 type state struct {
 	ingeneric bool
 	indefault bool
+	inexport  bool
+	exported  []*ast.FunctionLiteral
 	namespace []string
 	incall    int
+	// call on the right of pkg::fn
+	nscall *ast.CallExpression
+	// types returned by the functions being walked, innermost last
+	returned []ast.Types
 }
 
-func New() *Walker {
+func New(backend r.Backend) *Walker {
 	return &Walker{
-		env: environment.NewGlobalEnvironment(),
+		env:     environment.NewGlobalEnvironment(backend),
+		backend: backend,
 	}
 }
 
@@ -81,7 +89,18 @@ func (w *Walker) Walk(node ast.Node) (ast.Types, ast.Node) {
 		w.walkDecoratorDefault(node)
 		w.state.indefault = false
 
+	case *ast.DecoratorExport:
+		w.walkDecoratorExport(node)
+
+	case *ast.DecoratorChecked:
+		w.walkDecoratorChecked(node)
+
 	case *ast.Keyword:
+		// ... forwards the arguments of the enclosing function
+		if node.Value == "..." {
+			v, _ := w.env.GetVariable("...", true)
+			return v.Value, node
+		}
 		return ast.Types{node.Type}, node
 
 	case *ast.Null:
@@ -171,6 +190,9 @@ func (w *Walker) walkProgram(program *ast.Program) (ast.Types, ast.Node) {
 		}
 	}
 
+	w.warnUnexportedTypes()
+	w.checkGenerics()
+
 	return types, node
 }
 
@@ -187,6 +209,32 @@ func (w *Walker) walkCallExpression(node *ast.CallExpression) (ast.Types, ast.No
 		return w.walkKnownCallExpression(node, fn.Value)
 	}
 
+	// pkg::fn() declared in the types of pkg
+	if node == w.state.nscall {
+		pkg := w.currentNamespace()
+		pfn, ok := w.env.GetPackageFunction(pkg, node.Function)
+
+		if ok {
+			return w.walkTypedCallExpression(node, pfn)
+		}
+
+		me, ok := w.env.GetPackageMethods(pkg, node.Function)
+
+		if ok {
+			return w.walkKnownCallMethodExpression(node, me)
+		}
+
+		t, ok := w.env.GetType(pkg, node.Function)
+
+		if ok {
+			return w.walkKnownCallTypeExpression(node, t)
+		}
+	}
+
+	if exists && fn.Typed && node != w.state.nscall {
+		return w.walkTypedCallExpression(node, fn)
+	}
+
 	me, exists := w.env.GetMethods(node.Name)
 
 	if exists && fn.Package == "" {
@@ -216,9 +264,29 @@ func (w *Walker) walkCallExpression(node *ast.CallExpression) (ast.Types, ast.No
 		w.checkIfIdentifier(v.Value)
 	}
 
+	// function from base R, e.g.: paste
+	if fn.Package != "" && node != w.state.nscall {
+		w.checkFormals(node, fn.Package, "::")
+	}
+
 	return ast.Types{}, node
 }
 
+// stubs returning any are as untyped as R
+func (w *Walker) walkTypedCallExpression(node *ast.CallExpression, fn environment.Function) (ast.Types, ast.Node) {
+	types, n := w.walkKnownCallExpression(node, fn.Value)
+
+	for _, v := range node.Arguments {
+		w.checkIfIdentifier(v.Value)
+	}
+
+	if acceptAny(types) {
+		return ast.Types{}, n
+	}
+
+	return types, n
+}
+
 func (w *Walker) walkCallExpressionMissing(node *ast.CallExpression) (ast.Types, ast.Node) {
 	for _, v := range node.Arguments {
 		w.callIfIdentifier(v.Value, func(node *ast.Identifier) {
@@ -256,6 +324,10 @@ func (w *Walker) walkKnownCallTypeExpression(node *ast.CallExpression, t environ
 		return w.walkKnownCallTypeImpliedListExpression(node, t)
 	}
 
+	if t.Object == "dataframe" {
+		return w.walkKnownCallTypeDataframeExpression(node, t)
+	}
+
 	for _, v := range node.Arguments {
 		w.Walk(v.Value)
 		w.checkIfIdentifier(v.Value)
@@ -419,6 +491,39 @@ func (w *Walker) walkKnownCallTypeStructExpression(node *ast.CallExpression, t e
 	return ast.Types{}, node
 }
 
+// columns are vectors of the attribute types
+func (w *Walker) walkKnownCallTypeDataframeExpression(node *ast.CallExpression, t environment.Type) (ast.Types, ast.Node) {
+	columns := make(map[string]bool)
+	for _, v := range node.Arguments {
+		at, _ := w.Walk(v.Value)
+		if v.Name == "" {
+			w.addFatalf(
+				v.Token,
+				"dataframe expects named arguments",
+			)
+			continue
+		}
+		w.checkIfIdentifier(v.Value)
+		w.attributeMatch(v, at, t)
+		columns[v.Name] = true
+	}
+
+	for _, a := range t.Attributes {
+		if columns[a.Name] {
+			continue
+		}
+
+		w.addWarnf(
+			node.Token,
+			"`%v` missing column `%v`",
+			t.Name,
+			a.Name,
+		)
+	}
+
+	return ast.Types{{Name: t.Name, Package: t.Package}}, node
+}
+
 func (w *Walker) walkKnownCallTypeObjectExpression(node *ast.CallExpression, t environment.Type) (ast.Types, ast.Node) {
 	for _, v := range node.Arguments {
 		at, _ := w.Walk(v.Value)
@@ -453,11 +558,18 @@ func (w *Walker) walkKnownCallMethodExpression(node *ast.CallExpression, ms envi
 		return ast.Types{}, node
 	}
 
-	for _, m := range ms {
-		if m.Value.Method.Name != t[0].Name {
-			continue
+	// nothing to dispatch on, the generic describes the call
+	if t[0].Name == "any" {
+		m, ok := ms.Generic()
+
+		if ok {
+			return w.walkKnownCallExpression(node, m.Value)
 		}
+	}
+
+	m, ok := w.env.Dispatch(ms, t[0])
 
+	if ok {
 		return w.walkKnownCallExpression(node, m.Value)
 	}
 
@@ -474,10 +586,22 @@ func (w *Walker) walkKnownCallMethodExpression(node *ast.CallExpression, ms envi
 func (w *Walker) walkKnownCallExpression(node *ast.CallExpression, fn *ast.FunctionLiteral) (ast.Types, ast.Node) {
 	dots := hasElipsis(fn.Parameters)
 
-	for argumentIndex, argument := range node.Arguments {
-		argumentType, _ := w.Walk(argument.Value)
+	walked := make(map[string][]ast.Types)
+	for _, b := range bindArguments(node.Arguments) {
+		argument := b.argument
+		argumentIndex := b.index
+
+		// the receiver of a method is not one of its parameters
+		position := argumentIndex
+		if fn.Method != nil {
+			position--
+		}
+
+		param, ok := getFunctionParameter(fn.Parameters, argument.Name, position)
 
-		param, ok := getFunctionParameter(fn.Parameters, argument.Name, argumentIndex)
+		w.typeLambda(argument.Value, param, fn, walked)
+		argumentType, _ := w.Walk(argument.Value)
+		walked[param.Name] = append(walked[param.Name], argumentType)
 
 		// it's method call
 		if argumentIndex == 0 && fn.Method != nil {
@@ -568,6 +692,37 @@ func (w *Walker) walkKnownCallExpression(node *ast.CallExpression, fn *ast.Funct
 	return fn.ReturnType, node
 }
 
+type boundArgument struct {
+	argument ast.Argument
+	index    int
+}
+
+// arguments with their position, anonymous functions
+// come last as their types may depend on the others
+func bindArguments(arguments []ast.Argument) []boundArgument {
+	var bound, lambdas []boundArgument
+
+	index := -1
+	for _, argument := range arguments {
+		// the closing bracket of x[i] is parsed as an argument
+		if _, ok := argument.Value.(*ast.Square); ok {
+			continue
+		}
+
+		index++
+		b := boundArgument{argument: argument, index: index}
+
+		if fn, ok := argument.Value.(*ast.FunctionLiteral); ok && fn.Name == "" {
+			lambdas = append(lambdas, b)
+			continue
+		}
+
+		bound = append(bound, b)
+	}
+
+	return append(bound, lambdas...)
+}
+
 func hasElipsis(params []*ast.Parameter) bool {
 	for _, p := range params {
 		if p.Name == "..." {
@@ -586,6 +741,11 @@ func getFunctionParameter(params []*ast.Parameter, name string, index int) (*ast
 		if name == "" && i == index {
 			return p, true
 		}
+
+		// positional arguments past ... go to ...
+		if name == "" && p.Name == "..." && i < index {
+			return p, true
+		}
 	}
 
 	return &ast.Parameter{}, false
@@ -697,52 +857,34 @@ func (w *Walker) walkInfixExpressionDollar(node *ast.InfixExpression) (ast.Types
 	}
 
 	rt, rn := w.Walk(node.Right)
+	attr, ok := rn.(*ast.Attribute)
 
-	// we check that the attributes exist on the type
-	w.callIfIdentifier(ln, func(ln *ast.Identifier) {
-		switch rn := rn.(type) {
-		case *ast.Attribute:
-			if len(lt) == 0 {
-				return
-			}
-
-			if lt[0].Name == "any" {
-				return
-			}
-
-			t, exists := w.env.GetType(lt[0].Package, lt[0].Name)
-
-			if !exists {
-				return
-			}
-
-			valid := []string{}
+	if !ok || len(lt) == 0 || lt[0].Name == "any" || lt[0].List {
+		return rt, rn
+	}
 
-			for _, a := range t.Attributes {
-				valid = append(valid, a.Name)
-			}
+	t, exists := w.env.GetType(lt[0].Package, lt[0].Name)
 
-			if !contains(rn.Value, valid) {
-				w.addFatalf(
-					rn.Token,
-					"`%v` unknown attribute on `%v`",
-					rn.Value,
-					ln.Value,
-				)
-				break
-			}
+	if !exists {
+		return rt, rn
+	}
 
-			for _, a := range t.Attributes {
-				if rn.Value != a.Name {
-					continue
-				}
+	// we check that the attribute exists on the type, its type
+	// is that of the left of nested access, e.g.: config$db$host
+	at, ok := w.getAttribute(attr.Value, t.Attributes)
 
-				rt = a.Type
-			}
-		}
-	})
+	if !ok {
+		w.addFatalf(
+			attr.Token,
+			"`%v` unknown attribute on `%v`",
+			attr.Value,
+			t.Name,
+		)
+		w.suggest(attr.Token, attr.Value, attributeNames(t))
+		return ast.Types{}, rn
+	}
 
-	return rt, rn
+	return at, rn
 }
 
 func (w *Walker) walkInfixExpressionRange(node *ast.InfixExpression) (ast.Types, ast.Node) {
@@ -776,6 +918,14 @@ func (w *Walker) walkInfixExpressionRange(node *ast.InfixExpression) (ast.Types,
 }
 
 func (w *Walker) walkInfixExpressionPipe(node *ast.InfixExpression) (ast.Types, ast.Node) {
+	call, ok := node.Right.(*ast.CallExpression)
+
+	// checked as the call with the left-hand side
+	// inserted as first argument, x |> f(y) is f(x, y)
+	if ok {
+		return w.Walk(pipedCall(node, call))
+	}
+
 	w.Walk(node.Left)
 
 	if node.Right == nil {
@@ -788,6 +938,18 @@ func (w *Walker) walkInfixExpressionPipe(node *ast.InfixExpression) (ast.Types,
 	return w.Walk(node.Right)
 }
 
+// a copy, the tree is transpiled as written
+func pipedCall(node *ast.InfixExpression, call *ast.CallExpression) *ast.CallExpression {
+	arguments := []ast.Argument{{Token: node.Token, Value: node.Left}}
+
+	return &ast.CallExpression{
+		Token:     call.Token,
+		Function:  call.Function,
+		Name:      call.Name,
+		Arguments: append(arguments, call.Arguments...),
+	}
+}
+
 func (w *Walker) walkInfixExpressionComparison(node *ast.InfixExpression) (ast.Types, ast.Node) {
 	lt, ln := w.Walk(node.Left)
 
@@ -816,7 +978,7 @@ func (w *Walker) walkInfixExpressionComparison(node *ast.InfixExpression) (ast.T
 func (w *Walker) walkInfixExpressionSquare(node *ast.InfixExpression) (ast.Types, ast.Node) {
 	lt, ln := w.Walk(node.Left)
 
-	ok := w.validAccessType(lt)
+	ok := w.validIndexType(lt)
 
 	if !ok {
 		w.addFatalf(
@@ -837,7 +999,16 @@ func (w *Walker) walkInfixExpressionSquare(node *ast.InfixExpression) (ast.Types
 		return ast.Types{}, node
 	}
 
-	return w.Walk(node.Right)
+	// df[, "col"] indexes the columns
+	column, ok := node.Right.(*ast.InfixExpression)
+	if ok && column.Operator == "," {
+		_, rn := w.Walk(column.Right)
+		return w.indexTypes(column.Operator, lt, column.Right), rn
+	}
+
+	_, rn := w.Walk(node.Right)
+
+	return w.indexTypes(node.Operator, lt, node.Right), rn
 }
 
 func (w *Walker) walkInfixExpressionDefault(node *ast.InfixExpression) (ast.Types, ast.Node) {
@@ -893,13 +1064,16 @@ func (w *Walker) walkInfixExpressionNamespaceInternal(node *ast.InfixExpression)
 
 func (w *Walker) walkInfixExpressionNS(node *ast.InfixExpression, operator string) (ast.Types, ast.Node) {
 	w.addNamespace(node.Left.Item().Value)
+	nscall := w.state.nscall
+	w.state.nscall, _ = node.Right.(*ast.CallExpression)
 	defer func() {
 		w.popNamespace()
+		w.state.nscall = nscall
 	}()
 
 	_, ln := w.Walk(node.Left)
 
-	exists, err := r.PackageIsInstalled(ln.Item().Value)
+	exists, err := w.backend.PackageIsInstalled(ln.Item().Value)
 
 	if err != nil {
 		w.addInfof(
@@ -917,7 +1091,17 @@ func (w *Walker) walkInfixExpressionNS(node *ast.InfixExpression, operator strin
 		)
 	}
 
-	w.env.LoadPackageTypes(ln.Item().Value)
+	source, loaded := w.env.LoadPackageTypes(ln.Item().Value)
+
+	// stubs override the types the package ships
+	if loaded && source.Stub {
+		w.addHintf(
+			ln.Item(),
+			"types of `%v` from %v",
+			ln.Item().Value,
+			source.Path,
+		)
+	}
 
 	if node.Right == nil {
 		w.addFatalf(
@@ -933,11 +1117,11 @@ func (w *Walker) walkInfixExpressionNS(node *ast.InfixExpression, operator strin
 		// we could be calling a type from another package
 		_, isType := w.env.GetType(ln.Item().Value, n.Function)
 
-		if isType {
+		if isType || w.hasPackageSignature(ln.Item().Value, n.Function) {
 			break
 		}
 
-		exists, err = r.PackageHasFunction(ln.Item().Value, operator, n.Function)
+		exists, err = w.backend.PackageHasFunction(ln.Item().Value, operator, n.Function)
 
 		if err != nil {
 			w.addInfof(
@@ -957,7 +1141,11 @@ func (w *Walker) walkInfixExpressionNS(node *ast.InfixExpression, operator strin
 				operator,
 				n.Function,
 			)
+			w.suggest(functionToken(ln.Item(), operator, n.Function), n.Function, w.packageFunctions(ln.Item().Value))
+			break
 		}
+
+		w.checkFormals(n, ln.Item().Value, operator)
 	}
 
 	return rt, rn
@@ -1110,6 +1298,7 @@ func (w *Walker) walkReturnStatement(node *ast.ReturnStatement) (ast.Types, ast.
 	t, n := w.Walk(node.ReturnValue)
 
 	w.checkIfIdentifier(n)
+	w.addReturned(t)
 
 	if w.env.ReturnType() != nil {
 		ok := w.typesValid(w.env.ReturnType(), t)
@@ -1264,6 +1453,74 @@ func (w *Walker) walkDecoratorGeneric(node *ast.DecoratorGeneric) {
 	w.Walk(node.Func)
 }
 
+func (w *Walker) walkDecoratorExport(node *ast.DecoratorExport) {
+	if node.Func == nil && node.Type == nil {
+		w.addFatalf(
+			node.Token,
+			"expecting function or type",
+		)
+		return
+	}
+
+	if node.Type != nil {
+		w.Walk(node.Type)
+		w.setTypeExported(node.Type.Name)
+		return
+	}
+
+	w.state.inexport = true
+	w.Walk(node.Func)
+	w.state.inexport = false
+
+	switch n := node.Func.(type) {
+	case *ast.DecoratorClass:
+		w.setTypeExported(n.Type.Name)
+	case *ast.DecoratorMatrix:
+		w.setTypeExported(n.Type.Name)
+	case *ast.DecoratorFactor:
+		w.setTypeExported(n.Type.Name)
+	case *ast.FunctionLiteral, *ast.DecoratorGeneric, *ast.DecoratorDefault, *ast.DecoratorChecked:
+		// flagged as exported in walkNamedFunctionLiteral
+	default:
+		w.addFatalf(
+			node.Token,
+			"can only export functions, methods, and types",
+		)
+	}
+}
+
+func (w *Walker) walkDecoratorChecked(node *ast.DecoratorChecked) {
+	if node.Func == nil {
+		w.addFatalf(
+			node.Token,
+			"expecting function",
+		)
+		return
+	}
+
+	switch node.Func.(type) {
+	case *ast.FunctionLiteral, *ast.DecoratorExport, *ast.DecoratorDefault:
+	default:
+		w.addFatalf(
+			node.Token,
+			"can only check functions and methods",
+		)
+	}
+
+	w.Walk(node.Func)
+}
+
+func (w *Walker) setTypeExported(name string) {
+	t, exists := w.env.GetType("", name)
+
+	if !exists {
+		return
+	}
+
+	t.Exported = true
+	w.env.SetType(t)
+}
+
 func (w *Walker) walkDecoratorClass(node *ast.DecoratorClass) (ast.Types, ast.Node) {
 	w.env.SetClass(
 		node.Type.Name,
@@ -1413,6 +1670,14 @@ func (w *Walker) walkFunctionLiteral(node *ast.FunctionLiteral) {
 }
 
 func (w *Walker) walkNamedFunctionLiteral(node *ast.FunctionLiteral) {
+	// only applies to this function, not those nested in its body
+	exported := w.state.inexport
+	w.state.inexport = false
+
+	if exported {
+		w.state.exported = append(w.state.exported, node)
+	}
+
 	_, exists := w.env.GetFunction(node.Name, false)
 
 	// we don't flag if it's a method
@@ -1446,7 +1711,7 @@ func (w *Walker) walkNamedFunctionLiteral(node *ast.FunctionLiteral) {
 	}
 
 	if node.Method == nil {
-		w.env.SetFunction(node.Name, environment.Function{Token: node.Token, Value: node})
+		w.env.SetFunction(node.Name, environment.Function{Token: node.Token, Value: node, Exported: exported})
 	}
 
 	methods, exists := w.env.GetMethods(node.Name)
@@ -1467,10 +1732,19 @@ func (w *Walker) walkNamedFunctionLiteral(node *ast.FunctionLiteral) {
 	}
 
 	if node.Method != nil {
-		w.env.AddMethod(node.Name, environment.Method{Token: node.Token, Value: node})
+		w.env.AddMethod(
+			node.Name,
+			environment.Method{
+				Token:    node.Token,
+				Value:    node,
+				Exported: exported,
+				Generic:  w.state.ingeneric,
+				Default:  w.state.indefault,
+			},
+		)
 	}
 
-	w.env = environment.Enclose(w.env, node.ReturnType)
+	w.enterFunction(node)
 
 	// we set the parameters in the environment
 	// and check that we don't have duplicates
@@ -1493,6 +1767,14 @@ func (w *Walker) walkNamedFunctionLiteral(node *ast.FunctionLiteral) {
 			w.Walk(p.Default)
 		}
 
+		if p.Type == nil {
+			w.addFatalf(
+				p.Token,
+				"parameter `%v` expects a type",
+				p.Name,
+			)
+		}
+
 		// we should not check if ... is used, it's always optional
 		// for now, we skip this check if the variable may
 		// actually be a function signature: to change in the future.
@@ -1533,13 +1815,8 @@ func (w *Walker) walkNamedFunctionLiteral(node *ast.FunctionLiteral) {
 
 	hasReturn := false
 	if node.Body != nil {
-		for _, s := range node.Body.Statements {
-			_, ln := w.Walk(s)
-			switch ln.(type) {
-			case *ast.ReturnStatement:
-				hasReturn = true
-			}
-		}
+		w.walkFunctionBody(node.Body)
+		hasReturn = w.returns(node.Body.Statements)
 	}
 
 	mustReturn := mustReturn(node.ReturnType)
@@ -1553,7 +1830,7 @@ func (w *Walker) walkNamedFunctionLiteral(node *ast.FunctionLiteral) {
 	}
 
 	w.warnUnusedVariables()
-	w.env = environment.Open(w.env)
+	w.exitFunction(node)
 }
 
 func mustReturn(types ast.Types) bool {
@@ -1567,7 +1844,7 @@ func mustReturn(types ast.Types) bool {
 }
 
 func (w *Walker) walkAnonymousFunctionLiteral(node *ast.FunctionLiteral) {
-	w.env = environment.Enclose(w.env, node.ReturnType)
+	w.enterFunction(node)
 
 	// we set the parameters in the environment
 	// and check that we don't have duplicates
@@ -1577,6 +1854,15 @@ func (w *Walker) walkAnonymousFunctionLiteral(node *ast.FunctionLiteral) {
 			w.Walk(p.Default)
 		}
 
+		// neither annotated nor passed where we know the signature
+		if p.Type == nil {
+			w.addWarnf(
+				p.Token,
+				"cannot infer the type of `%v`",
+				p.Name,
+			)
+		}
+
 		paramsObject := environment.Variable{
 			Token:   p.Token,
 			Value:   p.Type,
@@ -1601,13 +1887,12 @@ func (w *Walker) walkAnonymousFunctionLiteral(node *ast.FunctionLiteral) {
 	}
 
 	if node.Body != nil {
-		for _, s := range node.Body.Statements {
-			w.Walk(s)
-		}
+		w.walkFunctionBody(node.Body)
+		w.returns(node.Body.Statements)
 	}
 
 	w.warnUnusedVariables()
-	w.env = environment.Open(w.env)
+	w.exitFunction(node)
 }
 
 func (w *Walker) walkSquare(node *ast.Square) (ast.Types, ast.Node) {
@@ -1641,6 +1926,26 @@ func (w *Walker) popNamespace() {
 	w.state.namespace = w.state.namespace[:len(w.state.namespace)-1]
 }
 
+func (w *Walker) currentNamespace() string {
+	if !w.isInNamespace() {
+		return ""
+	}
+
+	return w.state.namespace[len(w.state.namespace)-1]
+}
+
+// the types of the package declare the function,
+// they take precedence over what R reports
+func (w *Walker) hasPackageSignature(pkg, name string) bool {
+	if _, ok := w.env.GetPackageFunction(pkg, name); ok {
+		return true
+	}
+
+	_, ok := w.env.GetPackageMethods(pkg, name)
+
+	return ok
+}
+
 func (w *Walker) isInNamespace() bool {
 	return len(w.state.namespace) > 0
 }
@@ -2,8 +2,12 @@ package walker
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/vapourlang/vapour/ast"
 	"github.com/vapourlang/vapour/diagnostics"
 	"github.com/vapourlang/vapour/environment"
 	"github.com/vapourlang/vapour/lexer"
@@ -11,6 +15,19 @@ import (
 	"github.com/vapourlang/vapour/r"
 )
 
+// tests run without R, see testdata/r.json
+var backend = loadBackend()
+
+func loadBackend() r.Backend {
+	snapshot, err := r.LoadSnapshot("testdata/r.json")
+
+	if err != nil {
+		panic(err)
+	}
+
+	return snapshot
+}
+
 func (w *Walker) testDiagnostics(t *testing.T, expected diagnostics.Diagnostics) {
 	if len(w.Errors()) != len(expected) {
 		w.Errors().Print()
@@ -66,7 +83,7 @@ const v: int | na = 1
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -96,7 +113,7 @@ func (x: int) bar(x: int): int {return x + y}
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 	w.Run(prog)
 
 	expected := diagnostics.Diagnostics{
@@ -133,7 +150,7 @@ let s: int = sum(1,2,3)
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -182,7 +199,7 @@ lg("hello", something = 1)
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 	w.Run(prog)
 
 	expected := diagnostics.Diagnostics{
@@ -224,7 +241,7 @@ func h(dat: dataset): char {
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -269,7 +286,7 @@ func foo(y: int): int {
 		p.Errors().Print()
 	}
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -341,7 +358,7 @@ inline(
 		return
 	}
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -386,7 +403,7 @@ y <- 2
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 	w.Run(prog)
 
 	expected := diagnostics.Diagnostics{
@@ -416,6 +433,7 @@ func foo(n: int): int {
   return x
 
   # should fail, returns does not exist
+  # should warn, unreachable
   return u
 }
 `
@@ -427,20 +445,631 @@ func foo(n: int): int {
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
+
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Warn},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Warn},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Warn},
+		{Severity: diagnostics.Warn},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestReturnPaths(t *testing.T) {
+	code := `
+# should fail, returns in one branch only
+func one(n: int = 1): int {
+  if (n == 1) {
+    return 1
+  }
+}
+
+func both(n: int = 1): int {
+  if (n == 1) {
+    return 1
+  } else {
+    return 2
+  }
+}
+
+func stops(n: int = 1): int {
+  if (n == 1) {
+    return 1
+  }
+
+  stop("not one")
+}
+
+# should fail, the loop may not run
+func loops(xs: int = 1): int {
+  for (let x: int in xs) {
+    return x
+  }
+}
+
+func forever(n: int = 1): int {
+  while (TRUE) {
+    n = n + 1
+  }
+}
+
+# should fail, the condition may be false
+func until(n: int = 1): int {
+  while (n < 10) {
+    return n
+  }
+}
+
+func deferred(n: int = 1): int {
+  defer (): null => {
+    print("done")
+  }
+
+  return n
+}
+
+func unreachable(n: int = 1): int {
+  if (n == 1) {
+    return 1
+    # should warn, unreachable
+    print("one")
+  }
+
+  return n
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Warn},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestInferReturn(t *testing.T) {
+	code := `
+@export
+func double(x: int = 1) {
+  return x * 2
+}
+
+let y: int = double(2)
+
+# should fail, returns an int
+let z: char = double(2)
+
+func either(x: int = 1) {
+  if (x > 1) {
+    return "big"
+  }
+
+  x
+}
+
+# should fail, returns int | char
+let e: int = either(2)
+
+let f: int | char = either(2)
+
+let add: any = (x: int, y: int) => {
+  return x + y
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+	}
+
+	w.testDiagnostics(t, expected)
+
+	fn, _ := w.Env().GetFunction("either", false)
+
+	if fn.Value.ReturnType.String() != "char, int" {
+		t.Fatalf("expected `either` to return char, int, got %v", fn.Value.ReturnType)
+	}
+
+	types := w.Env().GenerateTypes().String()
+
+	if !strings.Contains(types, "func double(x: int = 1): int") {
+		t.Fatalf("expected inferred return type in types:\n%v", types)
+	}
+}
+
+func TestLambdaContext(t *testing.T) {
+	code := `
+type math: func(int) int
+
+func apply_math(x: int = 1, fn: math = NULL): int {
+  return fn(x)
+}
+
+apply_math(2, (v) => {
+  return v * 2
+})
+
+# should fail, returns a char
+apply_math(2, (v) => {
+  return "a"
+})
+
+let xs: int = c(1, 2, 3)
+
+# should fail, x is an int
+lapply(xs, (x) => {
+  return x + "a"
+})
+
+# should fail, named functions are typed
+func untyped(x) {
+  return 1
+}
+
+# should warn, nothing to infer from
+let f: any = (y) => {
+  return 1
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Info},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Warn},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestPipe(t *testing.T) {
+	code := `
+type person: object {
+  age: int,
+  name: char
+}
+
+func create(name: char = "anon"): person {
+  return person(name = name)
+}
+
+@generic
+func (p: any) set_age(age: int, ...: any): any
+
+func(p: person) set_age(age: int = 1, ...: any): person {
+  p$age = age
+  return p
+}
+
+let john: person = create("John") |>
+  set_age(36)
+
+# should fail, name expects a char
+let jane: person = 1 |> create()
+
+func inc(x: int = 1): int {
+  return x + 1
+}
+
+# should fail, the chain returns an int
+let y: char = 1 |> inc() |> inc()
+
+# should fail, age expects an int
+create("Bob") |> set_age("old")
+
+# should fail, no method on int
+1 |> set_age(2)
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestDispatch(t *testing.T) {
+	code := `
+type user: object {
+  name: char
+}
+
+@class(admin, user)
+type admin: object {
+  name: char
+}
+
+type guest: object {
+  name: char
+}
+
+type robot: object {
+  id: int
+}
+
+@generic
+func (x: any) greet(...: any): any
+
+@default
+func (x: any) greet(...: any): null {
+  print("hello")
+}
+
+func (x: user) greet(...: any): char {
+  return "hello user"
+}
+
+func (x: guest) greet(...: any): int {
+  return 1
+}
+
+@generic
+func (x: any) describe(...: any): any
+
+func (x: user) describe(...: any): char {
+  return "user"
+}
+
+let u: char = greet(user(name = "u"))
+
+# inherited from user
+let a: char = greet(admin(name = "root"))
+
+# resolved to the guest method
+let g: int = greet(guest(name = "bob"))
+
+# falls back to default
+let r: null = greet(robot(id = 1))
+
+# should fail, no default
+describe(robot(id = 1))
+
+# should fail, inherited returns char
+let n: int = describe(admin(name = "x"))
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestGenericConsistency(t *testing.T) {
+	code := `
+type user: object {
+  name: char
+}
+
+type guest: object {
+  name: char
+}
+
+type robot: object {
+  id: int
+}
+
+@generic
+func (x: any) greet(times: int, ...: any): char
+
+@default
+func (x: any) greet(times: int = 1, ...: any): char {
+  return rep("hello", times)
+}
+
+func (x: user) greet(times: int = 1, ...: any): char {
+  return rep("hello user", times)
+}
 
+# should fail, times is missing
+func (x: guest) greet(n: int = 1, ...: any): char {
+  return rep("hello guest", n)
+}
+
+# should fail, returns an int
+func (x: robot) greet(times: int = 1, ...: any): int {
+  return times
+}
+
+@generic
+func (x: any) describe(verbose: bool, ...: any): any
+
+# should fail, verbose is a bool
+# should warn, ... is dropped
+func (x: user) describe(verbose: char = "yes"): char {
+  return paste("user", verbose)
+}
+
+# should fail, the receiver is x in the generic
+func (g: guest) describe(verbose: bool = TRUE, ...: any): any {
+  return paste("guest", verbose)
+}
+
+# should warn, no methods
+@generic
+func (x: any) unused(...: any): any
+
+greet(user(name = "a"))
+greet(guest(name = "b"))
+greet(robot(id = 1))
+describe(user(name = "c"))
+describe(guest(name = "d"))
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
 	w.Run(prog)
 
 	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
 		{Severity: diagnostics.Warn},
 		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
 		{Severity: diagnostics.Warn},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestSubtype(t *testing.T) {
+	code := `
+type user: object {
+  name: char
+}
+
+@class(admin, user)
+type admin: object {
+  name: char
+}
+
+type ints: []int
+
+type nums: []num
+
+func half(x: num = 1): num {
+  return x / 2
+}
+
+func greet(u: user = NULL): char {
+  return u$name
+}
+
+func first(xs: nums = NULL): num {
+  return xs[[1]]
+}
+
+# int is promoted to num
+let h: num = half(1)
+
+# admin inherits from user
+greet(admin(name = "root"))
+
+# na_int is an int
+let n: int = na_int
+
+
+# should fail, user is not an admin
+func promote(a: admin = NULL): admin {
+  return a
+}
+
+promote(user(name = "y"))
+
+# []int is a []num
+first(ints(1, 2))
+
+# should fail, num is not int
+let i: int = 1.5
+
+# should fail, null is not an int
+let z: int = NULL
+
+# comparisons go both ways
+if (1 == 2.5) {
+  print("no")
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
 		{Severity: diagnostics.Fatal},
 		{Severity: diagnostics.Fatal},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestNestedAttributes(t *testing.T) {
+	code := `
+type database: object {
+  host: char,
+  port: int
+}
+
+type config: object {
+  name: char,
+  db: database
+}
+
+let cfg: config = config(name = "app", db = database(host = "localhost", port = 5432))
+
+let host: char = cfg$db$host
+
+# should fail, port is an int
+let port: char = cfg$db$port
+
+# should fail, did you mean host
+print(cfg$db$hots)
+
+# should fail, port expects an int
+cfg$db$port = "80"
+
+cfg$db$host = "remote"
+
+# should fail, did you mean db
+print(cfg$bd)
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+	}
+
+	w.testDiagnostics(t, expected)
+
+	if !strings.HasSuffix(w.Errors()[1].String(), "did you mean `host`?\n") {
+		t.Fatalf("expected a suggestion, got %v", w.Errors()[1])
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	code := `
+type user: object {
+  name: char,
+  email: char
+}
+
+let counter: int = 1
+
+# should warn, did you mean counter
+print(countr)
+
+# should fail, did you mean email
+let u: user = user(name = "a", emial = "b")
+
+# should fail, did you mean name
+let n: char = u[["nme"]]
+
+# should hint, did you mean filter
+dplyr::fitler(u)
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
 		{Severity: diagnostics.Warn},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Hint},
 	}
 
 	w.testDiagnostics(t, expected)
+
+	suggestions := []string{"counter", "email", "name", "filter"}
+
+	for i, d := range w.Errors() {
+		if len(d.Suggestions) != 1 || d.Suggestions[0].Value != suggestions[i] {
+			t.Fatalf("expected suggestion `%v`, got %v", suggestions[i], d.Suggestions)
+		}
+	}
+
+	// replaces the function, not the package
+	fn := w.Errors()[3].Suggestions[0].Token
+
+	if fn.Value != "fitler" || fn.Char != 13 {
+		t.Fatalf("expected suggestion to replace `fitler`, got %v at %v", fn.Value, fn.Char)
+	}
 }
 
 func TestSquare(t *testing.T) {
@@ -474,7 +1103,7 @@ func (p: any) meth(): null {}
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 	w.Run(prog)
 
 	expected := diagnostics.Diagnostics{
@@ -489,6 +1118,140 @@ func (p: any) meth(): null {}
 	w.testDiagnostics(t, expected)
 }
 
+func TestIndex(t *testing.T) {
+	code := `
+type ids: []int
+let i: ids = ids(1, 2)
+let first: int = i[1]
+let one: int = i[[2]]
+
+# should fail, x[cond] keeps the vector type
+type nums: num
+let x: nums = nums(1, 2, 3)
+let big: char = x[x > 1]
+
+# should fail, i[cond] is still ids
+let some: int = i[c(TRUE, FALSE)]
+
+type things: list { int | num }
+let lst: things = things(1, 2)
+let thing: int | num = lst[[1]]
+
+type user: object {
+  id: int,
+  name: char
+}
+
+let u: user = user(id = 1, name = "me")
+let name: char = u[["name"]]
+let id: int = u[[1]]
+
+# should fail, name is a char
+let wrong: int = u[["name"]]
+
+# should fail, unknown attribute
+u[["age"]]
+
+# should warn, out of range
+u[[3]]
+
+type person: struct {
+  int,
+  name: char
+}
+
+let pr: person = person(1, name = "me")
+
+# the underlying vector, not the attributes
+let v: int = pr[[1]]
+let third: int = pr[[3]]
+
+# should fail, pr[[1]] is an int
+let wrongv: char = pr[[1]]
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Warn},
+		{Severity: diagnostics.Fatal},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestDataframe(t *testing.T) {
+	code := `
+type rows: dataframe {
+  name: char,
+  age: int
+}
+
+let df: rows = rows(name = "me", age = 1)
+
+# should fail, age is an int
+let wrong: rows = rows(name = "me", age = "old")
+
+# should fail, unknown column
+let unknown: rows = rows(name = "me", age = 1, height = 2)
+
+# should warn, missing age
+let missing: rows = rows(name = "me")
+
+# should fail, named columns, and warn missing name
+let unnamed: rows = rows("me", age = 1)
+
+let n: char = df$name
+let a: int = df[["age"]]
+
+# should fail, age is an int
+let c: char = df[["age"]]
+
+# should fail, unknown column
+let h: int = df[["height"]]
+
+let col: int = df[, "age"]
+
+# should fail, age is an int
+let wrongcol: char = df[, "age"]
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Warn},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Warn},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
 func TestBasic(t *testing.T) {
 	code := `let x: int | na = 1
 
@@ -545,7 +1308,7 @@ uu = "char"
 		return
 	}
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -599,7 +1362,7 @@ person(2)
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -641,7 +1404,7 @@ let w: users = users(
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -683,7 +1446,7 @@ for(let i: int in y) {
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -722,7 +1485,7 @@ func baz(x: int): int {
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -752,7 +1515,7 @@ x += "aaah"
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -788,7 +1551,7 @@ type matty: matrix {
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -823,7 +1586,7 @@ type fct: factor {
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -881,7 +1644,7 @@ func foo(x: int): math {
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -944,7 +1707,7 @@ func(l: any) addRule(): null {}
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -986,7 +1749,7 @@ p$name = 2
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -1018,7 +1781,7 @@ type fct: factor { num | int }
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -1055,7 +1818,7 @@ p$dob = 1
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -1089,7 +1852,7 @@ func baz(): any {}
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -1113,6 +1876,7 @@ func (p: person) foo(x: int): any
 @generic
 func (p: any) foo(x: int): any
 
+# warn, no methods
 @generic
 func (p: any) bar(x: int): person
 `
@@ -1124,12 +1888,13 @@ func (p: any) bar(x: int): person
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
 	expected := diagnostics.Diagnostics{
 		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Warn},
 	}
 
 	w.testDiagnostics(t, expected)
@@ -1149,7 +1914,7 @@ require(package)
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -1181,7 +1946,7 @@ let globals: any = new.env(env = parent.env(), hash = TRUE)
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -1229,7 +1994,7 @@ print(c)
 
 	prog := p.Run()
 
-	w := New()
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -1261,8 +2026,9 @@ type custom: object {
 
 	prog := p.Run()
 
-	environment.SetLibrary(r.LibPath())
-	w := New()
+	library, _ := backend.LibPaths()
+	environment.SetLibrary(library)
+	w := New(backend)
 
 	w.Run(prog)
 
@@ -1274,3 +2040,415 @@ type custom: object {
 
 	w.testDiagnostics(t, expected)
 }
+
+func TestPackageSignatures(t *testing.T) {
+	code := `
+let x: vape::user = vape::make_user(1)
+
+# should fail, id is an int
+let y: vape::user = vape::make_user(id = "one")
+
+# should fail, returns a user
+let z: int = vape::make_user(2)
+
+let greeting: char = vape::greet(x, loud = TRUE)
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	library, _ := backend.LibPaths()
+	environment.SetLibrary(library)
+	w := New(backend)
+
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestGenerateSignatures(t *testing.T) {
+	code := `
+type user: object {
+  id: int
+}
+
+@export
+func make_user(id: int, name: char = "anon"): user {
+  return user(id = id)
+}
+
+func helper(x: int): int {
+  return x
+}
+
+@export
+func offset(x: int = -1, by: int = 1 + 1): int {
+  return x + by
+}
+
+@export
+@generic
+func (x: any) greet(loud: bool = FALSE): char
+
+@export
+func (x: user) greet(loud: bool = FALSE): char {
+  return "hello"
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+
+	w.Run(prog)
+
+	generated := w.Env().GenerateTypes()
+	types := generated.String()
+
+	if len(generated.Warnings()) != 1 {
+		t.Fatalf("expected a warning for the default of `by`, got %v", generated.Warnings())
+	}
+
+	expected := []string{
+		"func make_user(id: int, name: char = \"anon\"): user",
+		"func offset(x: int = -1, by: int = NULL): int",
+		"@generic\nfunc (x: any) greet(loud: bool = FALSE): char\nfunc (x: user) greet(loud: bool = FALSE): char",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Fatalf("expected `%v` in types, got:\n%v", e, types)
+		}
+	}
+
+	if strings.Contains(types, "helper") {
+		t.Fatalf("unexported function in types:\n%v", types)
+	}
+}
+
+func TestGenerateTypes(t *testing.T) {
+	code := `
+type user: object {
+  id: int,
+  friend: vape::user,
+  tags: []char
+}
+
+type id: int | char
+
+type ids: []int
+
+type things: list { int | num }
+
+@matrix(nrow = 2, ncol = 4)
+type grid: matrix { int }
+
+@factor(ordered = TRUE)
+type letters: factor { char }
+
+type point: struct {
+  num,
+  x: num,
+  y: num
+}
+
+type rows: dataframe {
+  name: char
+}
+
+@class(person, user)
+type person: object {
+  name: char
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+
+	w.Run(prog)
+
+	generated := w.Env().GenerateTypes().String()
+
+	for i := 0; i < 10; i++ {
+		if w.Env().GenerateTypes().String() != generated {
+			t.Fatalf("types generated in a different order")
+		}
+	}
+
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "roundtrip"), 0755)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "roundtrip", "types.vp"), []byte(generated), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	library, _ := backend.LibPaths()
+	environment.SetLibrary([]string{dir})
+	defer environment.SetLibrary(library)
+
+	env := environment.New()
+	_, ok := env.LoadPackageTypes("roundtrip")
+
+	if !ok {
+		t.Fatalf("failed to load generated types:\n%v", generated)
+	}
+
+	count := 0
+	for name, want := range w.Env().Types() {
+		if environment.IsNativeType(name) || environment.IsNativeObject(name) || want.Package != "" {
+			continue
+		}
+
+		count++
+		got, ok := env.GetType("roundtrip", name)
+
+		if !ok {
+			t.Fatalf("type `%v` not in generated types:\n%v", name, generated)
+		}
+
+		if describeType(got) != describeType(want) {
+			t.Fatalf(
+				"type `%v` does not round trip, expected\n%v\ngot\n%v",
+				name,
+				describeType(want),
+				describeType(got),
+			)
+		}
+	}
+
+	if count != len(env.Types()) {
+		t.Fatalf("expected %v types, got %v", count, len(env.Types()))
+	}
+
+	class, ok := env.GetClass("roundtrip::person")
+
+	if !ok || strings.Join(class.Value.Classes, ",") != "person,user" {
+		t.Fatalf("@class does not round trip:\n%v", generated)
+	}
+
+	if !strings.Contains(generated, "@matrix(nrow = 2, ncol = 4)\ntype grid") {
+		t.Fatalf("@matrix arguments not generated:\n%v", generated)
+	}
+
+	if !strings.Contains(generated, "@factor(ordered = TRUE)\ntype letters") {
+		t.Fatalf("@factor arguments not generated:\n%v", generated)
+	}
+
+	_, ok = env.GetMatrix("roundtrip::grid")
+
+	if !ok {
+		t.Fatalf("@matrix does not round trip:\n%v", generated)
+	}
+}
+
+// everything but the position and package
+func describeType(t environment.Type) string {
+	str := t.Name + ": " + t.Object + " " + typesString(t.Type)
+
+	for _, a := range t.Attributes {
+		str += "\n" + a.Name + ": " + typesString(a.Type)
+	}
+
+	return str
+}
+
+func typesString(types ast.Types) string {
+	var str []string
+	for _, t := range types {
+		str = append(str, fmt.Sprintf("%v/%v/%v", t.Package, t.Name, t.List))
+	}
+	return strings.Join(str, " | ")
+}
+
+func TestStubPaths(t *testing.T) {
+	code := `
+# project stub overrides the types vape ships
+let x: vape::user = vape::user(id = 1, name = "me")
+
+# stub pinned to the installed dplyr 1.1.4
+let g: dplyr::grouped = dplyr::grouped(groups = list("a"))
+
+# unversioned stub is shadowed
+let l: dplyr::legacy = dplyr::legacy(groups = "a")
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	library, _ := backend.LibPaths()
+	environment.SetLibrary(library)
+	environment.SetStubPaths([]string{"testdata/stubs"})
+	defer environment.SetStubPaths(nil)
+	w := New(backend)
+
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Hint},
+		{Severity: diagnostics.Hint},
+		{Severity: diagnostics.Hint},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestExport(t *testing.T) {
+	code := `
+type person: object {
+  name: char
+}
+
+@export
+type user: object {
+  id: int
+}
+
+@export
+func get_user(id: int = 1): user {
+  return user(id = id)
+}
+
+# should warn, person is not exported
+@export
+func create(name: char = "john"): person {
+  return person(name = name)
+}
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	if p.HasError() {
+		p.Errors().Print()
+		return
+	}
+
+	w := New(backend)
+
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Warn},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestFormals(t *testing.T) {
+	code := `
+# ok, partial match
+cor(c(1, 2), c(2, 3), meth = "kendall")
+
+# should fail, too many arguments
+cor(1, 2, "everything", "kendall", 3)
+
+# should fail, unknown argument and missing x
+cor(z = 1)
+
+# ok, passed to ...
+paste("a", "b", foo = 1)
+
+# ok
+dplyr::select(1, "x", "y")
+
+# should fail, .data is missing
+dplyr::select()
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Warn},
+		{Severity: diagnostics.Warn},
+	}
+
+	w.testDiagnostics(t, expected)
+}
+
+func TestStubs(t *testing.T) {
+	code := `type person: object {
+  name: char
+}
+
+let p: person = person(name = "john")
+
+# should fail, nchar expects char
+nchar(p)
+
+let n: int = nchar(p$name)
+
+# should fail, paste returns char
+let x: int = paste("a", "b")
+
+# should fail, toupper has no argument foo
+toupper("a", foo = 1)
+
+let found: bool = grepl("a", "abc", ignore.case = TRUE)
+
+print(n, x, found)
+`
+
+	l := lexer.NewTest(code)
+
+	l.Run()
+	p := parser.New(l)
+
+	prog := p.Run()
+
+	w := New(backend)
+
+	w.Run(prog)
+
+	expected := diagnostics.Diagnostics{
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+		{Severity: diagnostics.Fatal},
+	}
+
+	w.testDiagnostics(t, expected)
+}